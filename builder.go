@@ -0,0 +1,252 @@
+package zapLog
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// typed way to build the options for Init and NewLogger
+//
+//	options := zapLog.NewConfig().Level(zapLog.LogLevelDebug).MaxSize(10).Build()
+type ConfigBuilder_t struct {
+	options []LogOption_t
+}
+
+func NewConfig() *ConfigBuilder_t {
+	return &ConfigBuilder_t{
+		options: []LogOption_t{},
+	}
+}
+
+func (b *ConfigBuilder_t) Build() []LogOption_t {
+	return append([]LogOption_t{}, b.options...)
+}
+
+func (b *ConfigBuilder_t) set(option OptionType_e, value interface{}) *ConfigBuilder_t {
+	b.options = append(b.options, LogOption_t{
+		Option: option,
+		Value:  value,
+	})
+	return b
+}
+
+func (b *ConfigBuilder_t) Level(level LogLevel_e) *ConfigBuilder_t {
+	return b.set(OptionLogLevel, level)
+}
+
+func (b *ConfigBuilder_t) MaxSize(megabytes int) *ConfigBuilder_t {
+	return b.set(OptionLogMaxSize, megabytes)
+}
+
+func (b *ConfigBuilder_t) MaxBackup(count int) *ConfigBuilder_t {
+	return b.set(OptionLogMaxBackup, count)
+}
+
+func (b *ConfigBuilder_t) MaxAge(days int) *ConfigBuilder_t {
+	return b.set(OptionLogMaxAge, days)
+}
+
+func (b *ConfigBuilder_t) Compress(compress bool) *ConfigBuilder_t {
+	return b.set(OptionLogCompress, compress)
+}
+
+func (b *ConfigBuilder_t) DisableSave(disable bool) *ConfigBuilder_t {
+	return b.set(OptionLogDisableSave, disable)
+}
+
+func (b *ConfigBuilder_t) LocalTime(localTime bool) *ConfigBuilder_t {
+	return b.set(OptionLogLocalTime, localTime)
+}
+
+func (b *ConfigBuilder_t) ZapOptions(options ...zap.Option) *ConfigBuilder_t {
+	return b.set(OptionZapOptions, options)
+}
+
+func (b *ConfigBuilder_t) EncoderFormat(format EncoderFormat_e) *ConfigBuilder_t {
+	return b.set(OptionEncoderFormat, format)
+}
+
+func (b *ConfigBuilder_t) TimeFormat(layout string) *ConfigBuilder_t {
+	return b.set(OptionTimeFormat, layout)
+}
+
+func (b *ConfigBuilder_t) TimePrecision(precision TimePrecision_e) *ConfigBuilder_t {
+	return b.set(OptionTimePrecision, precision)
+}
+
+func (b *ConfigBuilder_t) TimeZone(location *time.Location) *ConfigBuilder_t {
+	return b.set(OptionTimeZone, location)
+}
+
+func (b *ConfigBuilder_t) AddCaller(addCaller bool) *ConfigBuilder_t {
+	return b.set(OptionAddCaller, addCaller)
+}
+
+func (b *ConfigBuilder_t) CallerSkip(skip int) *ConfigBuilder_t {
+	return b.set(OptionCallerSkip, skip)
+}
+
+func (b *ConfigBuilder_t) StacktraceLevel(level LogLevel_e) *ConfigBuilder_t {
+	return b.set(OptionStacktraceLevel, level)
+}
+
+func (b *ConfigBuilder_t) DisableConsole(disable bool) *ConfigBuilder_t {
+	return b.set(OptionDisableConsole, disable)
+}
+
+func (b *ConfigBuilder_t) ColorLevel(color bool) *ConfigBuilder_t {
+	return b.set(OptionColorLevel, color)
+}
+
+func (b *ConfigBuilder_t) LevelEncoder(style LevelEncoder_e) *ConfigBuilder_t {
+	return b.set(OptionLevelEncoder, style)
+}
+
+func (b *ConfigBuilder_t) EncoderKeys(keys EncoderKeys_t) *ConfigBuilder_t {
+	return b.set(OptionEncoderKeys, keys)
+}
+
+func (b *ConfigBuilder_t) SplitStderr(split bool) *ConfigBuilder_t {
+	return b.set(OptionSplitStderr, split)
+}
+
+func (b *ConfigBuilder_t) Hooks(hooks ...func(zapcore.Entry) error) *ConfigBuilder_t {
+	return b.set(OptionHooks, hooks)
+}
+
+func (b *ConfigBuilder_t) Sampling(sampling Sampling_t) *ConfigBuilder_t {
+	return b.set(OptionSampling, sampling)
+}
+
+func (b *ConfigBuilder_t) TraceExtractor(extractor func(context.Context) (string, string)) *ConfigBuilder_t {
+	return b.set(OptionTraceExtractor, extractor)
+}
+
+func (b *ConfigBuilder_t) RotateHook(hook func(string)) *ConfigBuilder_t {
+	return b.set(OptionRotateHook, hook)
+}
+
+func (b *ConfigBuilder_t) BufferSize(size int) *ConfigBuilder_t {
+	return b.set(OptionBufferSize, size)
+}
+
+func (b *ConfigBuilder_t) FlushInterval(interval time.Duration) *ConfigBuilder_t {
+	return b.set(OptionFlushInterval, interval)
+}
+
+func (b *ConfigBuilder_t) CustomEncoder(encoder zapcore.Encoder) *ConfigBuilder_t {
+	return b.set(OptionCustomEncoder, encoder)
+}
+
+func (b *ConfigBuilder_t) Discard(discard bool) *ConfigBuilder_t {
+	return b.set(OptionDiscard, discard)
+}
+
+func (b *ConfigBuilder_t) OnLevelChange(callback func(LogLevel_e, LogLevel_e)) *ConfigBuilder_t {
+	return b.set(OptionOnLevelChange, callback)
+}
+
+func (b *ConfigBuilder_t) InitialFields(fields map[string]interface{}) *ConfigBuilder_t {
+	return b.set(OptionInitialFields, fields)
+}
+
+func (b *ConfigBuilder_t) ConsoleHumanFileJSON(enable bool) *ConfigBuilder_t {
+	return b.set(OptionConsoleHumanFileJSON, enable)
+}
+
+func (b *ConfigBuilder_t) InternalErrorWriter(w io.Writer) *ConfigBuilder_t {
+	return b.set(OptionInternalErrorWriter, w)
+}
+
+func (b *ConfigBuilder_t) GoroutineID(enable bool) *ConfigBuilder_t {
+	return b.set(OptionGoroutineID, enable)
+}
+
+func (b *ConfigBuilder_t) Rotation(rotation Rotation_t) *ConfigBuilder_t {
+	return b.set(OptionRotation, rotation)
+}
+
+func (b *ConfigBuilder_t) Development(enable bool) *ConfigBuilder_t {
+	return b.set(OptionDevelopment, enable)
+}
+
+func (b *ConfigBuilder_t) RedactKeys(keys ...string) *ConfigBuilder_t {
+	return b.set(OptionRedactKeys, keys)
+}
+
+func (b *ConfigBuilder_t) SyncOnWrite(enable bool) *ConfigBuilder_t {
+	return b.set(OptionSyncOnWrite, enable)
+}
+
+func (b *ConfigBuilder_t) LineEnding(ending string) *ConfigBuilder_t {
+	return b.set(OptionLineEnding, ending)
+}
+
+func (b *ConfigBuilder_t) LinePrefix(prefix string) *ConfigBuilder_t {
+	return b.set(OptionLinePrefix, prefix)
+}
+
+func (b *ConfigBuilder_t) ZapLevel(level zapcore.Level) *ConfigBuilder_t {
+	return b.set(OptionZapLevel, level)
+}
+
+func (b *ConfigBuilder_t) MaxMessageBytes(size int) *ConfigBuilder_t {
+	return b.set(OptionMaxMessageBytes, size)
+}
+
+func (b *ConfigBuilder_t) DisableTimestamp(disable bool) *ConfigBuilder_t {
+	return b.set(OptionDisableTimestamp, disable)
+}
+
+func (b *ConfigBuilder_t) CallerStyle(style CallerStyle_e) *ConfigBuilder_t {
+	return b.set(OptionCallerStyle, style)
+}
+
+func (b *ConfigBuilder_t) OnRotate(fn func(RotateInfo)) *ConfigBuilder_t {
+	return b.set(OptionOnRotate, fn)
+}
+
+func (b *ConfigBuilder_t) DedupWindow(window time.Duration) *ConfigBuilder_t {
+	return b.set(OptionDedupWindow, window)
+}
+
+func (b *ConfigBuilder_t) ConsoleEncoder(format EncoderFormat_e) *ConfigBuilder_t {
+	return b.set(OptionConsoleEncoder, format)
+}
+
+func (b *ConfigBuilder_t) FileEncoder(format EncoderFormat_e) *ConfigBuilder_t {
+	return b.set(OptionFileEncoder, format)
+}
+
+func (b *ConfigBuilder_t) IncludeHost(enable bool) *ConfigBuilder_t {
+	return b.set(OptionIncludeHost, enable)
+}
+
+func (b *ConfigBuilder_t) IncludePID(enable bool) *ConfigBuilder_t {
+	return b.set(OptionIncludePID, enable)
+}
+
+func (b *ConfigBuilder_t) LevelFilter(levels ...LogLevel_e) *ConfigBuilder_t {
+	return b.set(OptionLevelFilter, levels)
+}
+
+func (b *ConfigBuilder_t) FileMode(mode os.FileMode) *ConfigBuilder_t {
+	return b.set(OptionFileMode, mode)
+}
+
+func (b *ConfigBuilder_t) DualEncode(enable bool) *ConfigBuilder_t {
+	return b.set(OptionDualEncode, enable)
+}
+
+func (b *ConfigBuilder_t) StableFieldOrder(enable bool) *ConfigBuilder_t {
+	return b.set(OptionStableFieldOrder, enable)
+}
+
+func (b *ConfigBuilder_t) FieldSampler(sampler FieldSampler_t) *ConfigBuilder_t {
+	return b.set(OptionFieldSampler, sampler)
+}
@@ -0,0 +1,36 @@
+package zapLog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigBuilder(t *testing.T) {
+	built := NewConfig().
+		Level(LogLevelDebug).
+		MaxSize(10).
+		EncoderFormat(EncoderFormatJson).
+		TimeZone(time.UTC).
+		AddCaller(true).
+		DisableConsole(true).
+		BufferSize(4096).
+		Build()
+	want := []LogOption_t{
+		{Option: OptionLogLevel, Value: LogLevelDebug},
+		{Option: OptionLogMaxSize, Value: 10},
+		{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		{Option: OptionTimeZone, Value: time.UTC},
+		{Option: OptionAddCaller, Value: true},
+		{Option: OptionDisableConsole, Value: true},
+		{Option: OptionBufferSize, Value: 4096},
+	}
+	if !reflect.DeepEqual(built, want) {
+		t.Errorf("Build() = %v, want %v", built, want)
+	}
+	for _, o := range built {
+		if err := checkOption(o); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package zapLog
+
+import (
+	"strings"
+	"sync"
+)
+
+type captureWriter_t struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// one entry per Write also with OptionBufferSize
+func (c *captureWriter_t) writesEntries() {}
+
+func (c *captureWriter_t) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = append(c.lines, strings.TrimRight(string(p), "\r\n"))
+	return len(p), nil
+}
+
+// entries logged while fn runs, without line endings, e.g. to check in a
+// test that a message was logged. fn has to log through a logger taken from
+// l while it runs, loggers taken before don't see the capture. Captures can
+// be nested, the outer one gets the entries of the inner one too.
+func (l *Logger) CaptureLogs(fn func()) []string {
+	c := &captureWriter_t{}
+	_, uid := l.AddWriter(c)
+	defer l.RemoveWriter(uid)
+	fn()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.lines...)
+}
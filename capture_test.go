@@ -0,0 +1,28 @@
+package zapLog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCaptureLogs(t *testing.T) {
+	for _, bufferSize := range []int{0, 4096} {
+		l, _ := newTestLogger(t, LogOption_t{Option: OptionBufferSize, Value: bufferSize})
+		l.GetLogger().Info("before")
+		var inner []string
+		outer := l.CaptureLogs(func() {
+			l.GetLogger().Info("x")
+			inner = l.CaptureLogs(func() {
+				l.GetLogger().Info("y")
+			})
+		})
+		l.GetLogger().Info("after")
+
+		if len(inner) != 1 || !strings.HasSuffix(inner[0], "\ty") {
+			t.Errorf("buffer size %d: inner capture = %q, want y", bufferSize, inner)
+		}
+		if len(outer) != 2 || !strings.HasSuffix(outer[0], "\tx") || !strings.HasSuffix(outer[1], "\ty") {
+			t.Errorf("buffer size %d: outer capture = %q, want x and y", bufferSize, outer)
+		}
+	}
+}
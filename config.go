@@ -0,0 +1,132 @@
+package zapLog
+
+import (
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// snapshot of the options in effect, options holding functions, encoders
+// or zap options are left out
+type Config_t struct {
+	Path                 string
+	LogLevel             LogLevel_e
+	MaxSize              int
+	MaxBackup            int
+	MaxAge               int
+	Compress             bool
+	DisableSave          bool
+	LocalTime            bool
+	EncoderFormat        EncoderFormat_e
+	TimeFormat           string
+	AddCaller            bool
+	CallerSkip           int
+	StacktraceLevel      *LogLevel_e
+	DisableConsole       bool
+	ColorLevel           bool
+	EncoderKeys          EncoderKeys_t
+	SplitStderr          bool
+	Sampling             *Sampling_t
+	BufferSize           int
+	FlushInterval        time.Duration
+	Discard              bool
+	TimeZone             *time.Location
+	ConsoleHumanFileJSON bool
+	TimePrecision        TimePrecision_e
+	LevelEncoder         LevelEncoder_e
+	GoroutineID          bool
+	Development          bool
+	RedactKeys           []string
+	SyncOnWrite          bool
+	LineEnding           string
+	LinePrefix           string
+	ZapLevel             *zapcore.Level
+	MaxMessageBytes      int
+	DisableTimestamp     bool
+	CallerStyle          CallerStyle_e
+	DedupWindow          time.Duration
+	ConsoleEncoder       *EncoderFormat_e
+	FileEncoder          *EncoderFormat_e
+	IncludeHost          bool
+	IncludePID           bool
+	LevelFilter          []LogLevel_e
+	FileMode             *os.FileMode
+	DualEncode           bool
+	StableFieldOrder     bool
+	FieldSampler         *FieldSampler_t
+}
+
+func (l *Logger) Config() Config_t {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	level, ok := l.optionTable[OptionLogLevel].(LogLevel_e)
+	if !ok {
+		level = LogLevelInfo
+	}
+	rotation := getRotation(l.optionTable)
+	config := Config_t{
+		Path:                 l.path,
+		LogLevel:             level,
+		MaxSize:              rotation.MaxSize,
+		MaxBackup:            rotation.MaxBackups,
+		MaxAge:               rotation.MaxAge,
+		Compress:             rotation.Compress,
+		DisableSave:          l.optionTable[OptionLogDisableSave].(bool),
+		LocalTime:            rotation.LocalTime,
+		EncoderFormat:        l.optionTable[OptionEncoderFormat].(EncoderFormat_e),
+		TimeFormat:           l.optionTable[OptionTimeFormat].(string),
+		AddCaller:            l.optionTable[OptionAddCaller].(bool),
+		CallerSkip:           l.optionTable[OptionCallerSkip].(int),
+		DisableConsole:       l.optionTable[OptionDisableConsole].(bool),
+		ColorLevel:           l.optionTable[OptionColorLevel].(bool),
+		EncoderKeys:          l.optionTable[OptionEncoderKeys].(EncoderKeys_t),
+		SplitStderr:          l.optionTable[OptionSplitStderr].(bool),
+		BufferSize:           l.optionTable[OptionBufferSize].(int),
+		FlushInterval:        l.optionTable[OptionFlushInterval].(time.Duration),
+		Discard:              l.optionTable[OptionDiscard].(bool),
+		ConsoleHumanFileJSON: l.optionTable[OptionConsoleHumanFileJSON].(bool),
+		TimePrecision:        l.optionTable[OptionTimePrecision].(TimePrecision_e),
+		LevelEncoder:         l.optionTable[OptionLevelEncoder].(LevelEncoder_e),
+		GoroutineID:          l.optionTable[OptionGoroutineID].(bool),
+		Development:          l.optionTable[OptionDevelopment].(bool),
+		RedactKeys:           append([]string{}, l.optionTable[OptionRedactKeys].([]string)...),
+		SyncOnWrite:          l.optionTable[OptionSyncOnWrite].(bool),
+		LineEnding:           l.optionTable[OptionLineEnding].(string),
+		LinePrefix:           l.optionTable[OptionLinePrefix].(string),
+		MaxMessageBytes:      l.optionTable[OptionMaxMessageBytes].(int),
+		DisableTimestamp:     l.optionTable[OptionDisableTimestamp].(bool),
+		CallerStyle:          l.optionTable[OptionCallerStyle].(CallerStyle_e),
+		DedupWindow:          l.optionTable[OptionDedupWindow].(time.Duration),
+		IncludeHost:          l.optionTable[OptionIncludeHost].(bool),
+		IncludePID:           l.optionTable[OptionIncludePID].(bool),
+		DualEncode:           l.optionTable[OptionDualEncode].(bool),
+		StableFieldOrder:     l.optionTable[OptionStableFieldOrder].(bool),
+	}
+	config.TimeZone, _ = l.optionTable[OptionTimeZone].(*time.Location)
+	if stacktraceLevel, ok := l.optionTable[OptionStacktraceLevel].(LogLevel_e); ok {
+		config.StacktraceLevel = &stacktraceLevel
+	}
+	if sampling, ok := l.optionTable[OptionSampling].(Sampling_t); ok {
+		config.Sampling = &sampling
+	}
+	if zapLevel, ok := l.optionTable[OptionZapLevel].(zapcore.Level); ok {
+		config.ZapLevel = &zapLevel
+	}
+	if consoleEncoder, ok := l.optionTable[OptionConsoleEncoder].(EncoderFormat_e); ok {
+		config.ConsoleEncoder = &consoleEncoder
+	}
+	if fileEncoder, ok := l.optionTable[OptionFileEncoder].(EncoderFormat_e); ok {
+		config.FileEncoder = &fileEncoder
+	}
+	if levels, ok := l.optionTable[OptionLevelFilter].([]LogLevel_e); ok {
+		config.LevelFilter = append([]LogLevel_e{}, levels...)
+	}
+	if mode, ok := l.optionTable[OptionFileMode].(os.FileMode); ok {
+		config.FileMode = &mode
+	}
+	if sampler, ok := l.optionTable[OptionFieldSampler].(FieldSampler_t); ok {
+		config.FieldSampler = &sampler
+	}
+	return config
+}
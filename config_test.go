@@ -0,0 +1,41 @@
+package zapLog
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionLogLevel, Value: LogLevelWarn},
+		LogOption_t{Option: OptionLogMaxSize, Value: 7},
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionStacktraceLevel, Value: LogLevelError},
+		LogOption_t{Option: OptionSampling, Value: Sampling_t{Initial: 5}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	config := l.Config()
+	if config.Path != path || config.LogLevel != LogLevelWarn || config.MaxSize != 7 || config.EncoderFormat != EncoderFormatJson || !config.DisableConsole {
+		t.Errorf("Config() = %+v", config)
+	}
+	if config.StacktraceLevel == nil || *config.StacktraceLevel != LogLevelError {
+		t.Errorf("StacktraceLevel = %v, want error", config.StacktraceLevel)
+	}
+	if config.Sampling == nil || config.Sampling.Initial != 5 {
+		t.Errorf("Sampling = %v, want Initial 5", config.Sampling)
+	}
+	if config.BufferSize != 0 || config.AddCaller {
+		t.Errorf("unset options are not the defaults: %+v", config)
+	}
+
+	l.ChangeLogLevel(LogLevelDebug)
+	if level := l.Config().LogLevel; level != LogLevelDebug {
+		t.Errorf("LogLevel after ChangeLogLevel = %v", level)
+	}
+}
@@ -0,0 +1,647 @@
+package zapLog
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// like zapcore.NewTee, but Write only reaches the cores enabled for the
+// entry level, so the wrapping cores below can write through it
+type teeCore_t []zapcore.Core
+
+func newTeeCore(cores ...zapcore.Core) zapcore.Core {
+	return teeCore_t(cores)
+}
+
+func (t teeCore_t) Enabled(level zapcore.Level) bool {
+	for _, c := range t {
+		if c.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeCore_t) With(fields []zapcore.Field) zapcore.Core {
+	cores := make(teeCore_t, len(t))
+	for i, c := range t {
+		cores[i] = c.With(fields)
+	}
+	return cores
+}
+
+func (t teeCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	for _, c := range t {
+		ce = c.Check(ent, ce)
+	}
+	return ce
+}
+
+func (t teeCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var err error
+	for _, c := range t {
+		if c.Enabled(ent.Level) {
+			err = multierr.Append(err, c.Write(ent, fields))
+		}
+	}
+	return err
+}
+
+func (t teeCore_t) Sync() error {
+	var err error
+	for _, c := range t {
+		err = multierr.Append(err, c.Sync())
+	}
+	return err
+}
+
+// adds the id of the logging goroutine to every entry, reading it means
+// formatting the stack header so it costs about a microsecond per entry
+type goroutineCore_t struct {
+	zapcore.Core
+}
+
+func (c goroutineCore_t) With(fields []zapcore.Field) zapcore.Core {
+	return goroutineCore_t{c.Core.With(fields)}
+}
+
+func (c goroutineCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c goroutineCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// Write runs on the goroutine that logged
+	fields = append(fields[:len(fields):len(fields)], zap.Uint64("goroutine", goroutineID()))
+	return c.Core.Write(ent, fields)
+}
+
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	// "goroutine 18 [running]:"
+	s := strings.TrimPrefix(string(buf[:n]), "goroutine ")
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		id, _ := strconv.ParseUint(s[:i], 10, 64)
+		return id
+	}
+	return 0
+}
+
+const redactedValue = "***"
+
+// replaces the values of fields with a redacted key, the fields attached by
+// With are redacted as well
+type redactCore_t struct {
+	zapcore.Core
+	keys map[string]struct{}
+}
+
+func newRedactCore(core zapcore.Core, keys []string) zapcore.Core {
+	keySet := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		keySet[key] = struct{}{}
+	}
+	return redactCore_t{Core: core, keys: keySet}
+}
+
+func (c redactCore_t) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore_t{Core: c.Core.With(c.redact(fields)), keys: c.keys}
+}
+
+func (c redactCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c redactCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.redact(fields))
+}
+
+// a copy is made only when a field matches, fields belongs to the caller
+func (c redactCore_t) redact(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, f := range fields {
+		if _, ok := c.keys[f.Key]; !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]zapcore.Field{}, fields...)
+		}
+		redacted[i] = zap.String(f.Key, redactedValue)
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+const truncatedSuffix = "…(truncated)"
+
+// cuts the message and string fields longer than maxBytes
+type truncateCore_t struct {
+	zapcore.Core
+	maxBytes int
+}
+
+func newTruncateCore(core zapcore.Core, maxBytes int) zapcore.Core {
+	return truncateCore_t{Core: core, maxBytes: maxBytes}
+}
+
+func (c truncateCore_t) With(fields []zapcore.Field) zapcore.Core {
+	return truncateCore_t{Core: c.Core.With(c.truncateFields(fields)), maxBytes: c.maxBytes}
+}
+
+func (c truncateCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c truncateCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.truncate(ent.Message)
+	return c.Core.Write(ent, c.truncateFields(fields))
+}
+
+// a copy is made only when a field is cut, fields belongs to the caller
+func (c truncateCore_t) truncateFields(fields []zapcore.Field) []zapcore.Field {
+	var truncated []zapcore.Field
+	for i, f := range fields {
+		if f.Type != zapcore.StringType || len(f.String) <= c.maxBytes {
+			continue
+		}
+		if truncated == nil {
+			truncated = append([]zapcore.Field{}, fields...)
+		}
+		truncated[i].String = c.truncate(f.String)
+	}
+	if truncated == nil {
+		return fields
+	}
+	return truncated
+}
+
+func (c truncateCore_t) truncate(s string) string {
+	if len(s) <= c.maxBytes {
+		return s
+	}
+	cut := c.maxBytes
+	// don't split a utf-8 sequence
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncatedSuffix
+}
+
+// writer that is told the level of each entry
+type levelWriter_i interface {
+	WriteLevel(level zapcore.Level, p []byte) error
+}
+
+type levelWriterCore_t struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	writer levelWriter_i
+}
+
+func newLevelWriterCore(enc zapcore.Encoder, writer levelWriter_i, level zapcore.LevelEnabler) zapcore.Core {
+	return &levelWriterCore_t{LevelEnabler: level, enc: enc, writer: writer}
+}
+
+func (c *levelWriterCore_t) With(fields []zapcore.Field) zapcore.Core {
+	clone := &levelWriterCore_t{LevelEnabler: c.LevelEnabler, enc: c.enc.Clone(), writer: c.writer}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *levelWriterCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *levelWriterCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	return c.writer.WriteLevel(ent.Level, buf.Bytes())
+}
+
+func (c *levelWriterCore_t) Sync() error {
+	return nil
+}
+
+// drops an entry whose level and message were seen less than window ago,
+// unlike the sampler a message that wasn't seen before always passes
+type dedupCore_t struct {
+	zapcore.Core
+	state *dedupState_t
+}
+
+type dedupState_t struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[dedupKey_t]*dedupEntry_t
+	// entries older than window are removed once per window
+	lastSweep time.Time
+}
+
+type dedupKey_t struct {
+	level zapcore.Level
+	msg   string
+}
+
+type dedupEntry_t struct {
+	start      time.Time
+	suppressed int
+}
+
+func newDedupCore(core zapcore.Core, window time.Duration) zapcore.Core {
+	return dedupCore_t{
+		Core: core,
+		state: &dedupState_t{
+			window: window,
+			seen:   map[dedupKey_t]*dedupEntry_t{},
+		},
+	}
+}
+
+func (c dedupCore_t) With(fields []zapcore.Field) zapcore.Core {
+	return dedupCore_t{Core: c.Core.With(fields), state: c.state}
+}
+
+// decides in Check and hands the entry to the Check of the wrapped core,
+// zap's sampler below only samples in Check
+func (c dedupCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	pass, suppressed := c.state.allow(ent)
+	if !pass {
+		return ce
+	}
+	if suppressed > 0 {
+		return c.Core.With([]zapcore.Field{zap.Int("suppressed", suppressed)}).Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// whether ent passes and how many of its repeats were dropped before it
+func (s *dedupState_t) allow(ent zapcore.Entry) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := ent.Time
+	defer s.sweep(now)
+
+	key := dedupKey_t{level: ent.Level, msg: ent.Message}
+	e, ok := s.seen[key]
+	if !ok {
+		s.seen[key] = &dedupEntry_t{start: now}
+		return true, 0
+	}
+	if now.Sub(e.start) < s.window {
+		e.suppressed++
+		return false, 0
+	}
+	suppressed := e.suppressed
+	e.start = now
+	e.suppressed = 0
+	return true, suppressed
+}
+
+// the caller must hold s.mu, the count of a message that doesn't come back
+// is lost
+func (s *dedupState_t) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.window {
+		return
+	}
+	for key, e := range s.seen {
+		if now.Sub(e.start) >= s.window {
+			delete(s.seen, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// OptionFieldSampler, the field is looked up in the entry and then in the
+// fields added by With
+type fieldSamplerCore_t struct {
+	zapcore.Core
+	state *fieldSamplerState_t
+	// value of the field set by With
+	value    string
+	hasValue bool
+}
+
+type fieldSamplerState_t struct {
+	mu      sync.Mutex
+	sampler FieldSampler_t
+	counts  map[fieldSamplerKey_t]*fieldSamplerCount_t
+	// counts of older ticks are removed once per tick
+	lastSweep time.Time
+}
+
+type fieldSamplerKey_t struct {
+	level zapcore.Level
+	value string
+}
+
+type fieldSamplerCount_t struct {
+	start time.Time
+	n     int
+}
+
+func newFieldSamplerCore(core zapcore.Core, sampler FieldSampler_t) zapcore.Core {
+	if sampler.Tick <= 0 {
+		sampler.Tick = time.Second
+	}
+	return fieldSamplerCore_t{
+		Core: core,
+		state: &fieldSamplerState_t{
+			sampler: sampler,
+			counts:  map[fieldSamplerKey_t]*fieldSamplerCount_t{},
+		},
+	}
+}
+
+func (c fieldSamplerCore_t) With(fields []zapcore.Field) zapcore.Core {
+	clone := c
+	clone.Core = c.Core.With(fields)
+	if value, ok := c.state.fieldValue(fields); ok {
+		clone.value = value
+		clone.hasValue = true
+	}
+	return clone
+}
+
+func (c fieldSamplerCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c fieldSamplerCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	value, ok := c.state.fieldValue(fields)
+	if !ok {
+		value, ok = c.value, c.hasValue
+	}
+	if ok && !c.state.allow(ent, value) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// value of the last field named Key
+func (s *fieldSamplerState_t) fieldValue(fields []zapcore.Field) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.Key != s.sampler.Key {
+			continue
+		}
+		if f.Type == zapcore.StringType {
+			return f.String, true
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		return fmt.Sprint(enc.Fields[f.Key]), true
+	}
+	return "", false
+}
+
+func (s *fieldSamplerState_t) allow(ent zapcore.Entry, value string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := ent.Time
+	defer s.sweep(now)
+
+	key := fieldSamplerKey_t{level: ent.Level, value: value}
+	count, ok := s.counts[key]
+	if !ok || now.Sub(count.start) >= s.sampler.Tick {
+		count = &fieldSamplerCount_t{start: now}
+		s.counts[key] = count
+	}
+	count.n++
+	if count.n <= s.sampler.Initial {
+		return true
+	}
+	return s.sampler.Thereafter > 0 && (count.n-s.sampler.Initial)%s.sampler.Thereafter == 0
+}
+
+// the caller must hold s.mu
+func (s *fieldSamplerState_t) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.sampler.Tick {
+		return
+	}
+	for key, count := range s.counts {
+		if now.Sub(count.start) >= s.sampler.Tick {
+			delete(s.counts, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// key of the field set by LogTo, it is not written
+const LabelKey = "logTo"
+
+// send the entry only to the writers added by AddLabeledWriter with label,
+// e.g. logger.Infow("login", zapLog.LogTo("audit"), "user", name). Entries
+// with a label that has no writer go to the other writers.
+func LogTo(label string) zap.Field {
+	return zap.String(LabelKey, label)
+}
+
+// sends entries tagged by LogTo to the cores of their label and all the
+// others to the default core
+type labelCore_t struct {
+	zapcore.Core
+	labeled map[string]zapcore.Core
+	// set by With
+	label string
+}
+
+func newLabelCore(core zapcore.Core, labeled map[string]zapcore.Core) zapcore.Core {
+	return labelCore_t{Core: core, labeled: labeled}
+}
+
+func (c labelCore_t) Enabled(level zapcore.Level) bool {
+	if c.Core.Enabled(level) {
+		return true
+	}
+	for _, core := range c.labeled {
+		if core.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c labelCore_t) With(fields []zapcore.Field) zapcore.Core {
+	label, fields := splitLabel(fields)
+	if label == "" {
+		label = c.label
+	}
+	labeled := make(map[string]zapcore.Core, len(c.labeled))
+	for l, core := range c.labeled {
+		labeled[l] = core.With(fields)
+	}
+	return labelCore_t{Core: c.Core.With(fields), labeled: labeled, label: label}
+}
+
+func (c labelCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c labelCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	label, fields := splitLabel(fields)
+	if label == "" {
+		label = c.label
+	}
+	if core, ok := c.labeled[label]; ok {
+		if !core.Enabled(ent.Level) {
+			return nil
+		}
+		return core.Write(ent, fields)
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func (c labelCore_t) Sync() error {
+	err := c.Core.Sync()
+	for _, core := range c.labeled {
+		err = multierr.Append(err, core.Sync())
+	}
+	return err
+}
+
+// the last LabelKey field and the fields without any of them
+func splitLabel(fields []zapcore.Field) (string, []zapcore.Field) {
+	label := ""
+	var rest []zapcore.Field
+	for i, f := range fields {
+		if f.Key != LabelKey || f.Type != zapcore.StringType {
+			if rest != nil {
+				rest = append(rest, f)
+			}
+			continue
+		}
+		if rest == nil {
+			rest = append([]zapcore.Field{}, fields[:i]...)
+		}
+		label = f.String
+	}
+	if rest == nil {
+		return label, fields
+	}
+	return label, rest
+}
+
+// writes every entry in the console and then the json format with a single
+// write, so both lines stay next to each other
+type dualEncodeCore_t struct {
+	zapcore.LevelEnabler
+	console zapcore.Encoder
+	json    zapcore.Encoder
+	out     zapcore.WriteSyncer
+}
+
+func newDualEncodeCore(console, json zapcore.Encoder, out zapcore.WriteSyncer, level zapcore.LevelEnabler) zapcore.Core {
+	return &dualEncodeCore_t{
+		LevelEnabler: level,
+		console:      console,
+		json:         json,
+		out:          out,
+	}
+}
+
+func (c *dualEncodeCore_t) With(fields []zapcore.Field) zapcore.Core {
+	clone := &dualEncodeCore_t{
+		LevelEnabler: c.LevelEnabler,
+		console:      c.console.Clone(),
+		json:         c.json.Clone(),
+		out:          c.out,
+	}
+	for _, f := range fields {
+		f.AddTo(clone.console)
+		f.AddTo(clone.json)
+	}
+	return clone
+}
+
+func (c *dualEncodeCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dualEncodeCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	human, err := c.console.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer human.Free()
+	machine, err := c.json.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	human.Write(machine.Bytes())
+	machine.Free()
+	if _, err := c.out.Write(human.Bytes()); err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		// same as zapcore, the process may exit after this entry
+		return c.out.Sync()
+	}
+	return nil
+}
+
+func (c *dualEncodeCore_t) Sync() error {
+	return c.out.Sync()
+}
+
+// drops every entry once the logger is draining, loggers taken before Drain
+// included
+type drainCore_t struct {
+	zapcore.Core
+	draining *int32
+}
+
+func (c drainCore_t) Enabled(level zapcore.Level) bool {
+	return atomic.LoadInt32(c.draining) == 0 && c.Core.Enabled(level)
+}
+
+func (c drainCore_t) With(fields []zapcore.Field) zapcore.Core {
+	return drainCore_t{Core: c.Core.With(fields), draining: c.draining}
+}
+
+func (c drainCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if atomic.LoadInt32(c.draining) != 0 {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
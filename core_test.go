@@ -0,0 +1,154 @@
+package zapLog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupMixedMessages(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionDedupWindow, Value: time.Hour})
+	for _, msg := range []string{"repeated", "repeated", "unique 1", "repeated", "unique 2", "repeated"} {
+		l.GetLogger().Info(msg)
+	}
+
+	lines := buf.lines()
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), lines)
+	}
+	for i, want := range []string{"repeated", "unique 1", "unique 2"} {
+		if !strings.HasSuffix(lines[i], "\t"+want) {
+			t.Errorf("line %d = %q, want message %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestDedupKeepsSampling(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionSampling, Value: Sampling_t{Initial: 2, Tick: time.Minute}},
+		// every entry passes the dedup
+		LogOption_t{Option: OptionDedupWindow, Value: time.Nanosecond},
+	)
+	for i := 0; i < 20; i++ {
+		l.GetLogger().Info("same")
+	}
+	if lines := buf.lines(); len(lines) != 2 {
+		t.Errorf("got %d lines, want 2 sampled: %q", len(lines), lines)
+	}
+}
+
+func TestFieldSamplerKeysIndependent(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionFieldSampler, Value: FieldSampler_t{Key: "user", Initial: 2, Thereafter: 3, Tick: time.Minute}})
+	alice := l.GetLogger().With("user", "alice")
+	for i := 0; i < 8; i++ {
+		alice.Info("alice")
+		l.GetLogger().Infow("bob", "user", "bob")
+		l.GetLogger().Info("nobody")
+	}
+
+	// the first 2 and then the 5th and 8th
+	for msg, want := range map[string]int{"alice": 4, "bob": 4, "nobody": 8} {
+		if got := strings.Count(buf.String(), "INFO\t"+msg); got != want {
+			t.Errorf("%d lines of %s, want %d", got, msg, want)
+		}
+	}
+}
+
+func TestFieldSamplerKeepsSampling(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionSampling, Value: Sampling_t{Initial: 2, Tick: time.Minute}},
+		LogOption_t{Option: OptionFieldSampler, Value: FieldSampler_t{Key: "user", Initial: 100, Tick: time.Minute}},
+	)
+	for i := 0; i < 20; i++ {
+		l.GetLogger().Infow("same", "user", "alice")
+	}
+	if lines := buf.lines(); len(lines) != 2 {
+		t.Errorf("got %d lines, want 2 sampled: %q", len(lines), lines)
+	}
+}
+
+func TestGoroutineID(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionGoroutineID, Value: true},
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	logger := l.GetLogger()
+	logger.Info("main")
+	done := make(chan struct{})
+	go func() {
+		logger.Info("other")
+		close(done)
+	}()
+	<-done
+
+	lines := buf.lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), lines)
+	}
+	first := decodeJSONLine(t, lines[0])["goroutine"]
+	second := decodeJSONLine(t, lines[1])["goroutine"]
+	if first == nil || second == nil {
+		t.Fatalf("goroutine field missing: %q", lines)
+	}
+	if first == second {
+		t.Errorf("both goroutines logged id %v", first)
+	}
+}
+
+func TestRedactKeys(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionRedactKeys, Value: []string{"password", "token"}},
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	logger := l.GetLogger().With("token", "abc123")
+	logger.Infow("login", "user", "bob", "password", "hunter2")
+
+	line := buf.lines()[0]
+	m := decodeJSONLine(t, line)
+	if m["password"] != redactedValue || m["token"] != redactedValue {
+		t.Errorf("values not redacted: %q", line)
+	}
+	if m["user"] != "bob" {
+		t.Errorf("user = %v, want bob", m["user"])
+	}
+	if strings.Contains(line, "hunter2") || strings.Contains(line, "abc123") {
+		t.Errorf("secret leaked: %q", line)
+	}
+}
+
+func TestMaxMessageBytes(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionMaxMessageBytes, Value: 8},
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	// "é" is two bytes starting at byte 7, the cut moves before it
+	l.GetLogger().Infow("abcdefgé and more", "body", strings.Repeat("x", 20), "short", "ok")
+
+	m := decodeJSONLine(t, buf.lines()[0])
+	if m["msg"] != "abcdefg"+truncatedSuffix {
+		t.Errorf("msg = %q", m["msg"])
+	}
+	if m["body"] != "xxxxxxxx"+truncatedSuffix {
+		t.Errorf("body = %q", m["body"])
+	}
+	if m["short"] != "ok" {
+		t.Errorf("short = %q", m["short"])
+	}
+}
+
+func TestLabeledWriter(t *testing.T) {
+	l, buf := newTestLogger(t)
+	audit := &syncBuffer_t{}
+	l.AddLabeledWriter("audit", audit)
+	logger := l.GetLogger()
+	logger.Infow("user deleted", LogTo("audit"))
+	logger.With(LogTo("audit")).Info("role changed")
+	logger.Info("regular entry")
+
+	if lines := audit.lines(); len(lines) != 2 || strings.Contains(audit.String(), "regular entry") {
+		t.Errorf("audit writer got %q", lines)
+	}
+	if strings.Contains(audit.String(), LabelKey) {
+		t.Errorf("label written as a field: %q", audit.String())
+	}
+	if lines := buf.lines(); len(lines) != 1 || !strings.Contains(lines[0], "regular entry") {
+		t.Errorf("default writer got %q", lines)
+	}
+}
@@ -0,0 +1,158 @@
+package zapLog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// drops the "\n" zap appends to every entry
+type noLineEndingEncoder_t struct {
+	zapcore.Encoder
+}
+
+func (e noLineEndingEncoder_t) Clone() zapcore.Encoder {
+	return noLineEndingEncoder_t{e.Encoder.Clone()}
+}
+
+func (e noLineEndingEncoder_t) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return buf, err
+	}
+	buf.TrimNewline()
+	return buf, nil
+}
+
+var prefixPool = buffer.NewPool()
+
+// the prefix is added per entry so it can't end up inside a line
+type linePrefixEncoder_t struct {
+	zapcore.Encoder
+	prefix string
+}
+
+func (e linePrefixEncoder_t) Clone() zapcore.Encoder {
+	return linePrefixEncoder_t{Encoder: e.Encoder.Clone(), prefix: e.prefix}
+}
+
+func (e linePrefixEncoder_t) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return buf, err
+	}
+	defer buf.Free()
+	line := prefixPool.Get()
+	line.AppendString(e.prefix)
+	line.Write(buf.Bytes())
+	return line, nil
+}
+
+// OptionStableFieldOrder, reorders the keys of the json lines written by the
+// wrapped encoder
+type stableOrderEncoder_t struct {
+	zapcore.Encoder
+	// entry keys in the order zap writes them, they stay in front
+	leading    []string
+	stacktrace string
+}
+
+func newStableOrderEncoder(encoder zapcore.Encoder, config zapcore.EncoderConfig) zapcore.Encoder {
+	leading := []string{}
+	for _, key := range []string{config.LevelKey, config.TimeKey, config.NameKey, config.CallerKey, config.FunctionKey, config.MessageKey} {
+		if key != "" && key != zapcore.OmitKey {
+			leading = append(leading, key)
+		}
+	}
+	return stableOrderEncoder_t{Encoder: encoder, leading: leading, stacktrace: config.StacktraceKey}
+}
+
+func (e stableOrderEncoder_t) Clone() zapcore.Encoder {
+	return stableOrderEncoder_t{Encoder: e.Encoder.Clone(), leading: e.leading, stacktrace: e.stacktrace}
+}
+
+func (e stableOrderEncoder_t) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return buf, err
+	}
+	line, err := e.sortKeys(buf.Bytes())
+	if err != nil {
+		// better the line in zap's order than no line
+		return buf, nil
+	}
+	buf.Reset()
+	buf.Write(line)
+	return buf, nil
+}
+
+type jsonField_t struct {
+	key   string
+	value json.RawMessage
+}
+
+func (e stableOrderEncoder_t) sortKeys(line []byte) ([]byte, error) {
+	end := bytes.LastIndexByte(line, '}')
+	if end < 0 {
+		return nil, errors.New("zapLog: not a json object")
+	}
+	dec := json.NewDecoder(bytes.NewReader(line[:end+1]))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, errors.New("zapLog: not a json object")
+	}
+	fields := []jsonField_t{}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		f := jsonField_t{key: t.(string)}
+		if err := dec.Decode(&f.value); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		ri, rj := e.rank(fields[i].key), e.rank(fields[j].key)
+		if ri != rj {
+			return ri < rj
+		}
+		return ri == len(e.leading) && fields[i].key < fields[j].key
+	})
+
+	out := &bytes.Buffer{}
+	keyEncoder := json.NewEncoder(out)
+	// same as zap
+	keyEncoder.SetEscapeHTML(false)
+	out.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		keyEncoder.Encode(f.key)
+		// Encode appends a newline
+		out.Truncate(out.Len() - 1)
+		out.WriteByte(':')
+		out.Write(f.value)
+	}
+	out.WriteByte('}')
+	out.Write(line[end+1:])
+	return out.Bytes(), nil
+}
+
+// position of the entry keys, the other fields in between share one rank
+func (e stableOrderEncoder_t) rank(key string) int {
+	for i, k := range e.leading {
+		if k == key {
+			return i
+		}
+	}
+	if key == e.stacktrace {
+		return len(e.leading) + 1
+	}
+	return len(e.leading)
+}
@@ -0,0 +1,345 @@
+package zapLog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestColorLevel(t *testing.T) {
+	tests := []struct {
+		format EncoderFormat_e
+		color  bool
+		want   bool
+	}{
+		{EncoderFormatConsole, false, false},
+		{EncoderFormatConsole, true, true},
+		{EncoderFormatJson, true, false},
+	}
+	for _, tt := range tests {
+		l, buf := newTestLogger(t,
+			LogOption_t{Option: OptionEncoderFormat, Value: tt.format},
+			LogOption_t{Option: OptionColorLevel, Value: tt.color},
+		)
+		l.GetLogger().Info("colored?")
+		if got := strings.Contains(buf.String(), "\x1b["); got != tt.want {
+			t.Errorf("format %v color %v: escape codes %v, want %v: %q", tt.format, tt.color, got, tt.want, buf.String())
+		}
+	}
+}
+
+// top level keys of a json line in the order they are written
+func jsonKeys(t *testing.T, line string) []string {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.Token()
+	keys := []string{}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			t.Fatalf("not a json line %q: %v", line, err)
+		}
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			t.Fatalf("not a json line %q: %v", line, err)
+		}
+	}
+	return keys
+}
+
+func TestStableFieldOrder(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionStableFieldOrder, Value: true},
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	logger := l.GetLogger()
+	logger.Infow("entry", "b", 1, "a", 2, "c", 3)
+	logger.With("c", 3).Infow("entry", "a", 2, "b", 1)
+
+	lines := buf.lines()
+	want := []string{"level", "ts", "msg", "a", "b", "c"}
+	for _, line := range lines {
+		if keys := jsonKeys(t, line); !reflect.DeepEqual(keys, want) {
+			t.Errorf("keys = %v, want %v", keys, want)
+		}
+	}
+}
+
+func TestEncoderKeys(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionAddCaller, Value: true},
+		LogOption_t{Option: OptionEncoderKeys, Value: EncoderKeys_t{
+			LevelKey:   "severity",
+			TimeKey:    "@timestamp",
+			MessageKey: "message",
+			CallerKey:  "source",
+			NameKey:    "component",
+		}},
+	)
+	l.GetLogger().Named("db").Info("renamed")
+
+	m := decodeJSONLine(t, buf.lines()[0])
+	for _, key := range []string{"severity", "@timestamp", "message", "source", "component"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("key %q missing: %v", key, m)
+		}
+	}
+	for _, key := range []string{"level", "ts", "msg", "caller", "logger"} {
+		if _, ok := m[key]; ok {
+			t.Errorf("default key %q still written: %v", key, m)
+		}
+	}
+}
+
+// prefixes every message
+type prefixMessageEncoder_t struct {
+	zapcore.Encoder
+}
+
+func (e prefixMessageEncoder_t) Clone() zapcore.Encoder {
+	return prefixMessageEncoder_t{e.Encoder.Clone()}
+}
+
+func (e prefixMessageEncoder_t) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	ent.Message = "custom: " + ent.Message
+	return e.Encoder.EncodeEntry(ent, fields)
+}
+
+func TestCustomEncoder(t *testing.T) {
+	encoder := prefixMessageEncoder_t{zapcore.NewConsoleEncoder(zap.NewProductionEncoderConfig())}
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionCustomEncoder, Value: zapcore.Encoder(encoder)})
+	l.GetLogger().With("k", "v").Info("first")
+	l.GetLogger().Info("second")
+
+	lines := buf.lines()
+	if len(lines) != 2 || !strings.Contains(lines[0], "custom: first") || !strings.Contains(lines[1], "custom: second") {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestTimeFormatEpochNanos(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionTimeFormat, Value: TimeFormatEpochNanos},
+	)
+	before := time.Now()
+	l.GetLogger().Info("epoch")
+	after := time.Now()
+
+	m := map[string]interface{}{}
+	dec := json.NewDecoder(strings.NewReader(buf.lines()[0]))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	num, ok := m["ts"].(json.Number)
+	if !ok {
+		t.Fatalf("ts = %#v, want a number", m["ts"])
+	}
+	nanos, err := num.Int64()
+	if err != nil {
+		t.Fatalf("ts %s is not an integer: %v", num, err)
+	}
+	if nanos < before.UnixNano() || nanos > after.UnixNano() {
+		t.Errorf("ts %d is not the unix nanoseconds of the entry, want between %d and %d", nanos, before.UnixNano(), after.UnixNano())
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+	times := map[string]time.Time{}
+	for name, location := range map[string]*time.Location{
+		"utc":    time.UTC,
+		"offset": time.FixedZone("IST", 5*3600+1800),
+	} {
+		l, buf := newTestLogger(t,
+			LogOption_t{Option: OptionTimeFormat, Value: time.RFC3339},
+			LogOption_t{Option: OptionTimeZone, Value: location},
+		)
+		l.GetLogger().Info("zoned")
+		ts, err := time.Parse(time.RFC3339, strings.Fields(buf.lines()[0])[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		times[name] = ts
+	}
+
+	if _, offset := times["utc"].Zone(); offset != 0 {
+		t.Errorf("utc time has offset %d", offset)
+	}
+	if _, offset := times["offset"].Zone(); offset != 5*3600+1800 {
+		t.Errorf("fixed zone time has offset %d", offset)
+	}
+	if diff := times["offset"].Sub(times["utc"]); diff < -time.Second || diff > time.Second {
+		t.Errorf("the zones differ by %v, want the same instant", diff)
+	}
+}
+
+// the first line written to stdout and to the log file by log
+func consoleAndFileLines(t *testing.T, log func(l *Logger), options ...LogOption_t) (string, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	stdout := captureOutput(t, &os.Stdout, func() {
+		l, err := NewLogger(path, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log(l)
+		l.Close()
+	})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.SplitN(stdout, "\n", 2)[0], strings.SplitN(string(data), "\n", 2)[0]
+}
+
+func TestConsoleHumanFileJSON(t *testing.T) {
+	stdout, file := consoleAndFileLines(t, func(l *Logger) {
+		l.GetLogger().Infow("split formats", "k", "v")
+	}, LogOption_t{Option: OptionConsoleHumanFileJSON, Value: true})
+
+	if m := decodeJSONLine(t, file); m["msg"] != "split formats" {
+		t.Errorf("file line = %q", file)
+	}
+	if !strings.Contains(stdout, "\tINFO\tsplit formats\t") {
+		t.Errorf("stdout line = %q, want the console format", stdout)
+	}
+}
+
+func TestTimePrecision(t *testing.T) {
+	for precision, digits := range map[TimePrecision_e]int{
+		TimePrecisionSeconds: 0,
+		TimePrecisionMillis:  3,
+		TimePrecisionMicros:  6,
+		TimePrecisionNanos:   9,
+	} {
+		l, buf := newTestLogger(t, LogOption_t{Option: OptionTimePrecision, Value: precision})
+		l.GetLogger().Info("precise")
+		// "2006-01-02 15:04:05.000\tINFO..."
+		clock := strings.Fields(buf.lines()[0])[1]
+		got := 0
+		if i := strings.Index(clock, "."); i >= 0 {
+			got = len(clock) - i - 1
+		}
+		if got != digits {
+			t.Errorf("precision %v: %q has %d fractional digits, want %d", precision, clock, got, digits)
+		}
+	}
+}
+
+func TestLevelEncoder(t *testing.T) {
+	for style, want := range map[LevelEncoder_e]string{
+		LevelEncoderCapital:        "\tWARN\t",
+		LevelEncoderLowercase:      "\twarn\t",
+		LevelEncoderCapitalColor:   "\t\x1b[33mWARN\x1b[0m\t",
+		LevelEncoderLowercaseColor: "\t\x1b[33mwarn\x1b[0m\t",
+	} {
+		l, buf := newTestLogger(t, LogOption_t{Option: OptionLevelEncoder, Value: style})
+		l.GetLogger().Warn("styled")
+		if line := buf.lines()[0]; !strings.Contains(line, want) {
+			t.Errorf("style %v: %q, want %q", style, line, want)
+		}
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	for _, ending := range []string{"\r\n", "\x00", ""} {
+		l, buf := newTestLogger(t, LogOption_t{Option: OptionLineEnding, Value: ending})
+		l.GetLogger().Info("first")
+		l.GetLogger().Info("second")
+
+		out := buf.String()
+		if !strings.Contains(out, "first"+ending) || !strings.HasSuffix(out, "second"+ending) {
+			t.Errorf("line ending %q: got %q", ending, out)
+		}
+		if ending != "" && strings.Count(out, ending) != 2 {
+			t.Errorf("line ending %q: got %q", ending, out)
+		}
+		if ending != "\r\n" && strings.Contains(out, "\n") {
+			t.Errorf("line ending %q: got a newline in %q", ending, out)
+		}
+	}
+}
+
+func TestLinePrefix(t *testing.T) {
+	for _, format := range []EncoderFormat_e{EncoderFormatConsole, EncoderFormatJson} {
+		l, buf := newTestLogger(t,
+			LogOption_t{Option: OptionLinePrefix, Value: "[worker-3] "},
+			LogOption_t{Option: OptionEncoderFormat, Value: format})
+		logger := l.GetLogger()
+		logger.Info("first")
+		logger.With("job", 1).Warn("second")
+		logger.Error("third")
+
+		lines := buf.lines()
+		if len(lines) != 3 {
+			t.Fatalf("got %d lines, want 3: %q", len(lines), lines)
+		}
+		for _, line := range lines {
+			if !strings.HasPrefix(line, "[worker-3] ") {
+				t.Errorf("format %v: line without prefix %q", format, line)
+			}
+		}
+	}
+}
+
+func TestDisableTimestamp(t *testing.T) {
+	for _, disable := range []bool{false, true} {
+		l, buf := newTestLogger(t,
+			LogOption_t{Option: OptionDisableTimestamp, Value: disable},
+			LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+		l.GetLogger().Info("entry")
+		if _, ok := decodeJSONLine(t, buf.lines()[0])["ts"]; ok == disable {
+			t.Errorf("disable %v: ts written = %v", disable, ok)
+		}
+	}
+
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionDisableTimestamp, Value: true})
+	l.GetLogger().Info("no time")
+	if line := buf.lines()[0]; !strings.HasPrefix(line, "INFO") {
+		t.Errorf("console line = %q, want it to start with the level", line)
+	}
+}
+
+func TestCallerStyle(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	styles := map[CallerStyle_e]string{
+		CallerStyleShort: filepath.Base(filepath.Dir(file)) + "/encoder_test.go:",
+		CallerStyleFull:  file + ":",
+		CallerStyleBase:  "\tencoder_test.go:",
+	}
+	for style, want := range styles {
+		l, buf := newTestLogger(t,
+			LogOption_t{Option: OptionAddCaller, Value: true},
+			LogOption_t{Option: OptionCallerStyle, Value: style})
+		l.GetLogger().Info("caller")
+		if line := buf.lines()[0]; !strings.Contains(line, want) {
+			t.Errorf("style %v: %q doesn't contain %q", style, line, want)
+		}
+	}
+}
+
+func TestConsoleAndFileEncoder(t *testing.T) {
+	stdout, file := consoleAndFileLines(t, func(l *Logger) {
+		l.GetLogger().Infow("own formats", "k", "v")
+	},
+		LogOption_t{Option: OptionConsoleEncoder, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionFileEncoder, Value: EncoderFormatConsole})
+
+	if m := decodeJSONLine(t, stdout); m["msg"] != "own formats" || m["k"] != "v" {
+		t.Errorf("stdout line = %q", stdout)
+	}
+	if !strings.Contains(file, "\tINFO\town formats\t") {
+		t.Errorf("file line = %q, want the console format", file)
+	}
+}
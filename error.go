@@ -0,0 +1,71 @@
+package zapLog
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// log err with every layer of its errors.Unwrap chain, outermost first, in
+// "error_chain" and the innermost one in "root_cause"
+func (l *Logger) LogError(level LogLevel_e, err error, msg string) {
+	l.logError(level, err, msg)
+}
+
+func (l *Logger) logError(level LogLevel_e, err error, msg string) {
+	if err == nil {
+		logAt(l.helperLogger(helperCallerSkip), level, msg)
+		return
+	}
+	chain := []string{}
+	root := err
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, e.Error())
+		root = e
+	}
+	logAt(l.helperLogger(helperCallerSkip), level, msg,
+		"error", err.Error(),
+		"error_chain", chain,
+		"root_cause", root.Error(),
+	)
+}
+
+// defer l.RecoverAndLog(false) logs a panic of the surrounding func at error
+// level with the stack and panics again when repanic is set
+func (l *Logger) RecoverAndLog(repanic bool) {
+	// recover only works when called by the deferred func itself
+	if v := recover(); v != nil {
+		l.logPanic(v, repanic)
+	}
+}
+
+func (l *Logger) logPanic(v interface{}, repanic bool) {
+	logAt(l.helperLogger(panicCallerSkip()), LogLevelError, "recovered from panic",
+		"panic", fmt.Sprint(v),
+		"stack", string(debug.Stack()),
+	)
+	l.Sync()
+	if repanic {
+		panic(v)
+	}
+}
+
+// the deferred func is called by the runtime, the caller of the entry is
+// the func that panicked: the frame after runtime.gopanic
+func panicCallerSkip() int {
+	pcs := make([]uintptr, 32)
+	// from logPanic on
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for i := 0; ; i++ {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			// logAt and logPanic come first
+			return i + 2
+		}
+		if !more {
+			return helperCallerSkip
+		}
+	}
+}
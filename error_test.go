@@ -0,0 +1,77 @@
+package zapLog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func panicking() {
+	panic("boom")
+}
+
+func TestRecoverAndLogCaller(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionAddCaller, Value: true},
+	)
+	func() {
+		defer l.RecoverAndLog(false)
+		panicking()
+	}()
+
+	lines := buf.lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	m := decodeJSONLine(t, lines[0])
+	if caller, _ := m["caller"].(string); !strings.Contains(caller, "error_test.go") {
+		t.Errorf("caller = %q, want the panicking func in error_test.go", caller)
+	}
+	if m["panic"] != "boom" {
+		t.Errorf("panic = %v, want boom", m["panic"])
+	}
+}
+
+func TestLogError(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	root := errors.New("connection refused")
+	err := fmt.Errorf("load config: %w", fmt.Errorf("fetch: %w", root))
+	l.LogError(LogLevelError, err, "startup failed")
+
+	m := decodeJSONLine(t, buf.lines()[0])
+	wantChain := []interface{}{
+		"load config: fetch: connection refused",
+		"fetch: connection refused",
+		"connection refused",
+	}
+	if !reflect.DeepEqual(m["error_chain"], wantChain) {
+		t.Errorf("error_chain = %v, want %v", m["error_chain"], wantChain)
+	}
+	if m["root_cause"] != "connection refused" || m["error"] != err.Error() {
+		t.Errorf("root_cause = %v, error = %v", m["root_cause"], m["error"])
+	}
+}
+
+func TestPanic(t *testing.T) {
+	// the buffer is flushed before the panic
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionAddCaller, Value: true})
+	defer func() {
+		if v := recover(); v != "cannot continue" {
+			t.Errorf("recovered %v, want the message", v)
+		}
+		lines := buf.lines()
+		if len(lines) != 1 || !strings.Contains(lines[0], "PANIC") || !strings.Contains(lines[0], `"state": "broken"`) {
+			t.Fatalf("got %q", lines)
+		}
+		if !strings.Contains(lines[0], "error_test.go") {
+			t.Errorf("caller is not the test: %q", lines[0])
+		}
+	}()
+	l.Panic("cannot continue", "state", "broken")
+	t.Error("Panic returned")
+}
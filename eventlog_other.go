@@ -0,0 +1,14 @@
+//go:build !windows
+
+package zapLog
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// the event log only exists on windows
+func (l *Logger) AddEventLogWriter(source string) (*zap.SugaredLogger, string, error) {
+	return nil, "", errors.New("zapLog: the event log is only available on windows")
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package zapLog
+
+import "testing"
+
+func TestEventLogWriterUnavailable(t *testing.T) {
+	l, _ := newTestLogger(t)
+	sugarLogger, uid, err := l.AddEventLogWriter("zapLog")
+	if err == nil || sugarLogger != nil || uid != "" {
+		t.Errorf("AddEventLogWriter = %v, %q, %v, want an error", sugarLogger, uid, err)
+	}
+	if uids := l.Writers(); len(uids) != 1 {
+		t.Errorf("Writers() = %v, want only the test writer", uids)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build windows
+
+package zapLog
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// every entry is reported with this event id
+const eventLogEventID = 1
+
+type eventLogWriter_t struct {
+	log *eventlog.Log
+}
+
+// report entries to the windows event log under source, error and above
+// become error events, warn warning events and the rest information events
+func (l *Logger) AddEventLogWriter(source string) (*zap.SugaredLogger, string, error) {
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, "", fmt.Errorf("zapLog: open event log %s: %w", source, err)
+	}
+	sugarLogger, uid := l.addWriter(writerInfo_t{
+		writer: &eventLogWriter_t{log: log},
+		owned:  true,
+	})
+	return sugarLogger, uid, nil
+}
+
+func (w *eventLogWriter_t) Write(p []byte) (int, error) {
+	return len(p), w.log.Info(eventLogEventID, string(p))
+}
+
+func (w *eventLogWriter_t) WriteLevel(level zapcore.Level, p []byte) error {
+	msg := string(p)
+	switch {
+	case level >= zapcore.ErrorLevel:
+		return w.log.Error(eventLogEventID, msg)
+	case level == zapcore.WarnLevel:
+		return w.log.Warning(eventLogEventID, msg)
+	default:
+		return w.log.Info(eventLogEventID, msg)
+	}
+}
+
+func (w *eventLogWriter_t) Close() error {
+	return w.log.Close()
+}
@@ -0,0 +1,58 @@
+package zapLog
+
+import (
+	"context"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// frames between the user and the sugared logger call in exitWith:
+// caller -> Fatal or Panic -> exitWith
+const exitCallerSkip = 2
+
+// log at fatal level, drain every writer and exit with status 1. Unlike
+// the Fatal of the sugared logger nothing waiting in a buffer or an http
+// batch is lost.
+func (l *Logger) Fatal(msg string, args ...interface{}) {
+	l.exitWith(false, msg, args...)
+}
+
+// log at panic level, flush the writers and panic with msg
+func (l *Logger) Panic(msg string, args ...interface{}) {
+	l.exitWith(true, msg, args...)
+}
+
+func (l *Logger) exitWith(panics bool, msg string, args ...interface{}) {
+	sugarLogger := l.helperLogger(exitCallerSkip)
+	if sugarLogger == nil {
+		if panics {
+			panic(msg)
+		}
+		os.Exit(1)
+	}
+	hook := exitHook_t{logger: l, panics: panics}
+	if panics {
+		sugarLogger.WithOptions(zap.WithPanicHook(hook)).Panicw(msg, args...)
+		return
+	}
+	sugarLogger.WithOptions(zap.WithFatalHook(hook)).Fatalw(msg, args...)
+}
+
+// runs after the entry was written
+type exitHook_t struct {
+	logger *Logger
+	panics bool
+}
+
+func (h exitHook_t) OnWrite(ce *zapcore.CheckedEntry, fields []zapcore.Field) {
+	if h.panics {
+		h.logger.Sync()
+		panic(ce.Message)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), signalDrainTimeout)
+	h.logger.Drain(ctx)
+	cancel()
+	os.Exit(1)
+}
@@ -0,0 +1,202 @@
+package zapLog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	httpTimeout    = 10 * time.Second
+	httpRetries    = 3
+	httpBackoff    = 500 * time.Millisecond
+	httpMaxBackoff = 5 * time.Second
+	// batches waiting for the sender goroutine
+	httpQueueSize = 16
+)
+
+// how long Close waits for a full queue and for the pending requests,
+// replaced in tests
+var httpCloseWait = httpTimeout
+
+// collects entries and POSTs them as a json array from its own goroutine,
+// a full queue drops the batch so logging never waits for the endpoint
+type httpWriter_t struct {
+	mu        sync.Mutex
+	url       string
+	client    *http.Client
+	batchSize int
+	batch     []json.RawMessage
+	closed    bool
+	queue     chan []json.RawMessage
+	done      chan struct{}
+}
+
+func newHTTPWriter(endpoint string, batchSize int, flushInterval time.Duration) (*httpWriter_t, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("zapLog: http writer url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("zapLog: http writer url %q is not http or https", endpoint)
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	w := &httpWriter_t{
+		url:       endpoint,
+		client:    &http.Client{Timeout: httpTimeout},
+		batchSize: batchSize,
+		queue:     make(chan []json.RawMessage, httpQueueSize),
+		done:      make(chan struct{}),
+	}
+	go w.run(flushInterval)
+	return w, nil
+}
+
+// the logger never buffers the writes of an http writer with
+// OptionBufferSize
+func (w *httpWriter_t) writesEntries() {}
+
+// every Write is one entry, json entries are sent as they are and other
+// encodings as json strings. Entries written after Close are dropped.
+func (w *httpWriter_t) Write(p []byte) (int, error) {
+	entry := entryJSON(p)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return len(p), nil
+	}
+	w.batch = append(w.batch, entry)
+	if len(w.batch) >= w.batchSize {
+		w.enqueue(w.takeBatch())
+	}
+	return len(p), nil
+}
+
+// send the pending entries without waiting for the request
+func (w *httpWriter_t) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	if batch := w.takeBatch(); batch != nil {
+		w.enqueue(batch)
+	}
+	return nil
+}
+
+// send the pending entries and wait until the queue is done, a stuck
+// endpoint is given httpCloseWait before the rest is dropped
+func (w *httpWriter_t) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	batch := w.takeBatch()
+	w.mu.Unlock()
+
+	// closed once httpCloseWait is over, both waits share it
+	expired := make(chan struct{})
+	timer := time.AfterFunc(httpCloseWait, func() { close(expired) })
+	defer timer.Stop()
+	if batch != nil {
+		select {
+		case w.queue <- batch:
+		case <-expired:
+		}
+	}
+	close(w.queue)
+	select {
+	case <-w.done:
+	case <-expired:
+	}
+	return nil
+}
+
+// the caller must hold w.mu
+func (w *httpWriter_t) takeBatch() []json.RawMessage {
+	if len(w.batch) == 0 {
+		return nil
+	}
+	batch := w.batch
+	w.batch = nil
+	return batch
+}
+
+// the caller must hold w.mu
+func (w *httpWriter_t) enqueue(batch []json.RawMessage) {
+	select {
+	case w.queue <- batch:
+	default:
+		// the endpoint is behind, drop instead of blocking the logger
+	}
+}
+
+func (w *httpWriter_t) run(flushInterval time.Duration) {
+	defer close(w.done)
+	var tick <-chan time.Time
+	if flushInterval > 0 {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case batch, ok := <-w.queue:
+			if !ok {
+				return
+			}
+			w.post(batch)
+		case <-tick:
+			w.mu.Lock()
+			batch := w.takeBatch()
+			w.mu.Unlock()
+			if batch != nil {
+				w.post(batch)
+			}
+		}
+	}
+}
+
+// retried with a growing delay, the batch is dropped when all attempts fail
+func (w *httpWriter_t) post(batch []json.RawMessage) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return
+	}
+	backoff := httpBackoff
+	for attempt := 0; attempt < httpRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > httpMaxBackoff {
+				backoff = httpMaxBackoff
+			}
+		}
+		resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return
+		}
+	}
+}
+
+func entryJSON(p []byte) json.RawMessage {
+	line := bytes.TrimRight(p, "\r\n")
+	if json.Valid(line) {
+		return append(json.RawMessage{}, line...)
+	}
+	s, _ := json.Marshal(string(line))
+	return s
+}
@@ -0,0 +1,122 @@
+package zapLog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collects the posted batches
+type httpRecorder_t struct {
+	mu      sync.Mutex
+	batches [][]map[string]interface{}
+}
+
+func (h *httpRecorder_t) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	batch := []map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.batches = append(h.batches, batch)
+}
+
+func (h *httpRecorder_t) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msgs := []string{}
+	for _, batch := range h.batches {
+		for _, entry := range batch {
+			msg, _ := entry["msg"].(string)
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func TestHTTPWriterBatches(t *testing.T) {
+	for _, bufferSize := range []int{0, 4096} {
+		recorder := &httpRecorder_t{}
+		server := httptest.NewServer(recorder)
+		l, _ := newTestLogger(t,
+			LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+			LogOption_t{Option: OptionBufferSize, Value: bufferSize},
+		)
+		if _, _, err := l.AddHTTPWriter(server.URL, 2, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range []string{"a", "b", "c"} {
+			l.GetLogger().Info(msg)
+		}
+		// Close sends the last batch and waits for the requests
+		l.Close()
+		server.Close()
+
+		msgs := recorder.messages()
+		if len(msgs) != 3 || msgs[0] != "a" || msgs[1] != "b" || msgs[2] != "c" {
+			t.Errorf("buffer size %d: posted %q, want a, b, c", bufferSize, msgs)
+		}
+		if len(recorder.batches) != 2 {
+			t.Errorf("buffer size %d: %d batches, want 2", bufferSize, len(recorder.batches))
+		}
+	}
+}
+
+func TestHTTPWriterCloseStuckEndpoint(t *testing.T) {
+	defer func(wait time.Duration) { httpCloseWait = wait }(httpCloseWait)
+	httpCloseWait = 50 * time.Millisecond
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	w, err := newHTTPWriter(server.URL, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one request is stuck and the queue is full
+	w.Write([]byte(`{"msg":"stuck"}`))
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < httpQueueSize; i++ {
+		w.Write([]byte(`{"msg":"queued"}`))
+	}
+	w.mu.Lock()
+	w.batch = append(w.batch, entryJSON([]byte(`{"msg":"pending"}`)))
+	w.mu.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		w.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on the stuck endpoint")
+	}
+	if n, err := w.Write([]byte(`{"msg":"late"}`)); err != nil || n == 0 {
+		t.Errorf("Write after Close = %d, %v, want it dropped silently", n, err)
+	}
+}
+
+func TestHTTPWriterAfterLoggerClose(t *testing.T) {
+	server := httptest.NewServer(&httpRecorder_t{})
+	defer server.Close()
+	internal := &syncBuffer_t{}
+	l, _ := newTestLogger(t, LogOption_t{Option: OptionInternalErrorWriter, Value: internal})
+	if _, _, err := l.AddHTTPWriter(server.URL, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	l.GetLogger().Info("after close")
+	if lines := internal.lines(); len(lines) != 0 {
+		t.Errorf("logging after Close reported %q", lines)
+	}
+}
@@ -1,27 +1,93 @@
 package zapLog
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os"
+	"reflect"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/natefinch/lumberjack"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 type OptionType_e int
+type writerKind_e int
 type LogLevel_e int
+type EncoderFormat_e int
+type TimePrecision_e int
+type LevelEncoder_e int
+type CallerStyle_e int
 
 type LogOption_t struct {
 	Option OptionType_e
 	Value  interface{}
 }
 
+// value of OptionEncoderKeys
+type EncoderKeys_t struct {
+	LevelKey   string
+	TimeKey    string
+	MessageKey string
+	CallerKey  string
+	// name of Named loggers
+	NameKey string
+}
+
+// value of OptionSampling, every Tick the first Initial entries with the
+// same level and message are logged, then every Thereafter-th one
+type Sampling_t struct {
+	Initial    int
+	Thereafter int
+	// defaults to one second
+	Tick time.Duration
+}
+
+// value of OptionFieldSampler, per level and value of the Key field the first
+// Initial entries of each Tick are written and then every Thereafter-th.
+// Entries without the field are not sampled.
+type FieldSampler_t struct {
+	Key        string
+	Initial    int
+	Thereafter int
+	// defaults to one second
+	Tick time.Duration
+}
+
+// value of OptionRotation, same meaning as the lumberjack.Logger fields
+type Rotation_t struct {
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+	Compress   bool
+	LocalTime  bool
+}
+
+// argument of OptionOnRotate
+type RotateInfo struct {
+	// the log file, it is empty again after the rotation
+	Filename string
+	// where the rotated content went, lumberjack may still compress it
+	Backup string
+	// of the log file when it was rotated
+	Size int64
+}
+
 type writerInfo_t struct {
 	uid    string
 	writer io.Writer
+	// nil follows OptionLogLevel
+	level *LogLevel_e
+	// created by zapLog and closed when removed
+	owned bool
+	kind  writerKind_e
+	// only gets entries tagged with LogTo(label)
+	label string
+	// OptionDualEncode
+	dualEncode bool
+	// gets the entries the writer failed to write, nil for none
+	fallback zapcore.WriteSyncer
 }
 
 const (
@@ -32,131 +98,496 @@ const (
 	OptionLogCompress
 	OptionLogDisableSave
 	OptionZapOptions
+	OptionEncoderFormat
+	OptionTimeFormat
+	OptionAddCaller
+	OptionCallerSkip
+	OptionStacktraceLevel
+	OptionDisableConsole
+	OptionLogLocalTime
+	OptionColorLevel
+	OptionEncoderKeys
+	OptionSplitStderr
+	OptionHooks
+	OptionSampling
+	OptionTraceExtractor
+	OptionRotateHook
+	OptionBufferSize
+	OptionFlushInterval
+	OptionCustomEncoder
+	OptionDiscard
+	OptionOnLevelChange
+	OptionTimeZone
+	OptionInitialFields
+	OptionConsoleHumanFileJSON
+	OptionTimePrecision
+	OptionInternalErrorWriter
+	OptionLevelEncoder
+	OptionGoroutineID
+	OptionRotation
+	OptionDevelopment
+	OptionRedactKeys
+	OptionSyncOnWrite
+	OptionLineEnding
+	OptionLinePrefix
+	OptionZapLevel
+	OptionMaxMessageBytes
+	OptionDisableTimestamp
+	OptionCallerStyle
+	OptionOnRotate
+	OptionDedupWindow
+	OptionConsoleEncoder
+	OptionFileEncoder
+	OptionIncludeHost
+	OptionIncludePID
+	OptionLevelFilter
+	OptionFileMode
+	OptionDualEncode
+	OptionStableFieldOrder
+	OptionFieldSampler
+)
+
+// the file and console writers are created by Init, everything else is
+// registered by the user and has a uid
+const (
+	writerKindUser writerKind_e = iota
+	writerKindFile
+	writerKindConsole
 )
 
 const (
 	LogLevelDebug LogLevel_e = iota
 	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelFatal
+)
+
+const (
+	defaultTimeFormat = "2006-01-02 15:04:05"
+	// use as OptionTimeFormat value to log raw unix nanoseconds
+	TimeFormatEpochNanos = "epochNanos"
+)
+
+const (
+	EncoderFormatConsole EncoderFormat_e = iota
+	EncoderFormatJson
+	// key=value pairs
+	EncoderFormatLogfmt
 )
 
-var optionTable = map[OptionType_e]interface{}{
-	OptionLogLevel:       LogLevelInfo,
-	OptionLogMaxSize:     1,
-	OptionLogMaxBackup:   10,
-	OptionLogMaxAge:      30,
-	OptionLogCompress:    false,
-	OptionLogDisableSave: false,
-	OptionZapOptions:     []zap.Option{},
+const (
+	TimePrecisionSeconds TimePrecision_e = iota
+	TimePrecisionMillis
+	TimePrecisionMicros
+	TimePrecisionNanos
+)
+
+const (
+	LevelEncoderCapital LevelEncoder_e = iota
+	LevelEncoderLowercase
+	// color styles fall back to plain text with the json encoder
+	LevelEncoderCapitalColor
+	LevelEncoderLowercaseColor
+)
+
+const (
+	// package/file.go:12
+	CallerStyleShort CallerStyle_e = iota
+	// /abs/path/package/file.go:12
+	CallerStyleFull
+	// file.go:12
+	CallerStyleBase
+)
+
+func newOptionTable() map[OptionType_e]interface{} {
+	return map[OptionType_e]interface{}{
+		OptionLogLevel:       LogLevelInfo,
+		OptionLogMaxSize:     1,
+		OptionLogMaxBackup:   10,
+		OptionLogMaxAge:      30,
+		OptionLogCompress:    false,
+		OptionLogDisableSave: false,
+		OptionZapOptions:     []zap.Option{},
+		OptionEncoderFormat:  EncoderFormatConsole,
+		OptionTimeFormat:     "",
+		OptionAddCaller:      false,
+		OptionCallerSkip:     0,
+		// LogLevel_e, no stacktrace when unset
+		OptionStacktraceLevel: nil,
+		OptionDisableConsole:  false,
+		OptionLogLocalTime:    false,
+		// console encoder only
+		OptionColorLevel:  false,
+		OptionEncoderKeys: EncoderKeys_t{},
+		OptionSplitStderr: false,
+		// called for every written entry
+		OptionHooks: []func(zapcore.Entry) error{},
+		// Sampling_t, no sampling when unset
+		OptionSampling: nil,
+		// func(context.Context) (traceID, spanID string) used by FromContext
+		OptionTraceExtractor: nil,
+		// func(oldPath string) called with the rotated file after each rotation,
+		// from a new goroutine when a write rotated the file so it may log
+		OptionRotateHook: nil,
+		// bytes, writes go straight through when 0
+		OptionBufferSize: 0,
+		// zap flushes every 30 seconds when 0
+		OptionFlushInterval: time.Duration(0),
+		// zapcore.Encoder replacing the console/json encoder
+		OptionCustomEncoder: nil,
+		// drop everything, no file and no stdout
+		OptionDiscard: false,
+		// func(old, new LogLevel_e) called by ChangeLogLevel
+		OptionOnLevelChange: nil,
+		// *time.Location, local time when unset
+		OptionTimeZone: nil,
+		// added to every entry, e.g. service or env
+		OptionInitialFields: map[string]interface{}{},
+		// json in the log file and console format on stdout
+		OptionConsoleHumanFileJSON: false,
+		// fractional seconds added to the default time format
+		OptionTimePrecision: TimePrecisionSeconds,
+		// where zap reports its own failures, e.g. a writer returning an error
+		OptionInternalErrorWriter: os.Stderr,
+		OptionLevelEncoder:        LevelEncoderCapital,
+		// adds a goroutine field, it reads the stack on every entry
+		OptionGoroutineID: false,
+		// Rotation_t, overrides OptionLogMaxSize, OptionLogMaxBackup,
+		// OptionLogMaxAge, OptionLogCompress and OptionLogLocalTime
+		OptionRotation: nil,
+		// zap development config: dev encoder keys, time and level encoding,
+		// caller, stacktraces from warn and DPanic panics
+		OptionDevelopment: false,
+		// values of fields with these keys are written as "***"
+		OptionRedactKeys: []string{},
+		// sync the writers after every entry, very slow, it also flushes the
+		// OptionBufferSize buffer every time
+		OptionSyncOnWrite: false,
+		// appended to every entry, e.g. "\r\n", empty for none
+		OptionLineEnding: zapcore.DefaultLineEnding,
+		// written before every entry, e.g. "[worker-3] "
+		OptionLinePrefix: "",
+		// zapcore.Level, overrides OptionLogLevel until the next ChangeLogLevel
+		OptionZapLevel: nil,
+		// longer messages and string field values are cut, 0 for no limit
+		OptionMaxMessageBytes: 0,
+		// leave out the time, e.g. when the container runtime adds one
+		OptionDisableTimestamp: false,
+		OptionCallerStyle:      CallerStyleShort,
+		// func(RotateInfo), called after every rotation like OptionRotateHook
+		OptionOnRotate: nil,
+		// a message repeated within the window is dropped, the next one that
+		// passes has the number dropped in "suppressed", 0 turns it off
+		OptionDedupWindow: time.Duration(0),
+		// EncoderFormat_e of stdout and stderr, nil follows OptionEncoderFormat
+		OptionConsoleEncoder: nil,
+		// EncoderFormat_e of the log files, nil follows OptionEncoderFormat
+		OptionFileEncoder: nil,
+		// add "host" with os.Hostname to every entry
+		OptionIncludeHost: false,
+		// add "pid" with os.Getpid to every entry
+		OptionIncludePID: false,
+		// []LogLevel_e, only these levels are written instead of OptionLogLevel
+		// and above, writers with their own level keep it
+		OptionLevelFilter: nil,
+		// os.FileMode of the log files, e.g. 0640 for a log shipping group, rotated
+		// files keep it. nil leaves it to lumberjack
+		OptionFileMode: nil,
+		// bool, every entry is written to the log file twice, in the console and then
+		// in the json format, e.g. for audit logs read by people and by tools
+		OptionDualEncode: false,
+		// bool, json lines start with the level, time, logger, caller, function and message
+		// keys followed by the other fields sorted by key and the stacktrace. Every
+		// line is parsed once more, which about doubles the cost of the json encoding
+		OptionStableFieldOrder: false,
+		// FieldSampler_t, sampling per value of a field instead of per message
+		OptionFieldSampler: nil,
+	}
 }
 
-var sugarLogger *zap.SugaredLogger
-var path string
-var zapOptions []zap.Option
-var writerList = []writerInfo_t{}
+var ErrSaveDisabled = errors.New("zapLog: saving to file is disabled")
 
-// log level -> -1 = debug, 0 = info
-func Init(logPath string, options ...LogOption_t) *zap.SugaredLogger {
-	optionHandler(options...)
-	path = logPath
-	logWriteInit()
-	sugarLogger = initLogger(optionTable[OptionZapOptions].([]zap.Option)...)
-	return sugarLogger
+// value type expected by each option
+var optionTypes = map[OptionType_e]reflect.Type{
+	OptionLogLevel:             reflect.TypeOf(LogLevelInfo),
+	OptionLogMaxSize:           reflect.TypeOf(0),
+	OptionLogMaxBackup:         reflect.TypeOf(0),
+	OptionLogMaxAge:            reflect.TypeOf(0),
+	OptionLogCompress:          reflect.TypeOf(false),
+	OptionLogDisableSave:       reflect.TypeOf(false),
+	OptionZapOptions:           reflect.TypeOf([]zap.Option{}),
+	OptionEncoderFormat:        reflect.TypeOf(EncoderFormatConsole),
+	OptionTimeFormat:           reflect.TypeOf(""),
+	OptionAddCaller:            reflect.TypeOf(false),
+	OptionCallerSkip:           reflect.TypeOf(0),
+	OptionStacktraceLevel:      reflect.TypeOf(LogLevelInfo),
+	OptionDisableConsole:       reflect.TypeOf(false),
+	OptionLogLocalTime:         reflect.TypeOf(false),
+	OptionColorLevel:           reflect.TypeOf(false),
+	OptionEncoderKeys:          reflect.TypeOf(EncoderKeys_t{}),
+	OptionSplitStderr:          reflect.TypeOf(false),
+	OptionHooks:                reflect.TypeOf([]func(zapcore.Entry) error{}),
+	OptionSampling:             reflect.TypeOf(Sampling_t{}),
+	OptionTraceExtractor:       reflect.TypeOf((func(context.Context) (string, string))(nil)),
+	OptionRotateHook:           reflect.TypeOf((func(string))(nil)),
+	OptionBufferSize:           reflect.TypeOf(0),
+	OptionFlushInterval:        reflect.TypeOf(time.Duration(0)),
+	OptionCustomEncoder:        reflect.TypeOf((*zapcore.Encoder)(nil)).Elem(),
+	OptionDiscard:              reflect.TypeOf(false),
+	OptionOnLevelChange:        reflect.TypeOf((func(LogLevel_e, LogLevel_e))(nil)),
+	OptionTimeZone:             reflect.TypeOf((*time.Location)(nil)),
+	OptionInitialFields:        reflect.TypeOf(map[string]interface{}{}),
+	OptionConsoleHumanFileJSON: reflect.TypeOf(false),
+	OptionTimePrecision:        reflect.TypeOf(TimePrecisionSeconds),
+	OptionInternalErrorWriter:  reflect.TypeOf((*io.Writer)(nil)).Elem(),
+	OptionLevelEncoder:         reflect.TypeOf(LevelEncoderCapital),
+	OptionGoroutineID:          reflect.TypeOf(false),
+	OptionRotation:             reflect.TypeOf(Rotation_t{}),
+	OptionDevelopment:          reflect.TypeOf(false),
+	OptionRedactKeys:           reflect.TypeOf([]string{}),
+	OptionSyncOnWrite:          reflect.TypeOf(false),
+	OptionLineEnding:           reflect.TypeOf(""),
+	OptionLinePrefix:           reflect.TypeOf(""),
+	OptionZapLevel:             reflect.TypeOf(zapcore.InfoLevel),
+	OptionMaxMessageBytes:      reflect.TypeOf(0),
+	OptionDisableTimestamp:     reflect.TypeOf(false),
+	OptionCallerStyle:          reflect.TypeOf(CallerStyleShort),
+	OptionOnRotate:             reflect.TypeOf(func(RotateInfo) {}),
+	OptionDedupWindow:          reflect.TypeOf(time.Duration(0)),
+	OptionConsoleEncoder:       reflect.TypeOf(EncoderFormatConsole),
+	OptionFileEncoder:          reflect.TypeOf(EncoderFormatConsole),
+	OptionIncludeHost:          reflect.TypeOf(false),
+	OptionIncludePID:           reflect.TypeOf(false),
+	OptionLevelFilter:          reflect.TypeOf([]LogLevel_e{}),
+	OptionFileMode:             reflect.TypeOf(os.FileMode(0)),
+	OptionDualEncode:           reflect.TypeOf(false),
+	OptionStableFieldOrder:     reflect.TypeOf(false),
+	OptionFieldSampler:         reflect.TypeOf(FieldSampler_t{}),
+}
+
+// package level functions work on this instance
+var defaultLogger = newLogger()
+
+// log level -> debug, info, warn, error, fatal
+func Init(logPath string, options ...LogOption_t) (*zap.SugaredLogger, error) {
+	if err := defaultLogger.init(logPath, options...); err != nil {
+		return nil, err
+	}
+	return defaultLogger.GetLogger(), nil
+}
+
+// same as Init but panics when the log path can't be used
+func MustInit(logPath string, options ...LogOption_t) *zap.SugaredLogger {
+	logger, err := Init(logPath, options...)
+	if err != nil {
+		panic(err)
+	}
+	return logger
 }
 
 func GetLogger() *zap.SugaredLogger {
-	return sugarLogger
+	return defaultLogger.GetLogger()
+}
+
+func FileOnly() *zap.SugaredLogger {
+	return defaultLogger.FileOnly()
+}
+
+func ConsoleOnly() *zap.SugaredLogger {
+	return defaultLogger.ConsoleOnly()
+}
+
+func GetCoreLogger() *zap.Logger {
+	return defaultLogger.GetCoreLogger()
 }
 
 func ChangeLogLevel(level LogLevel_e) *zap.SugaredLogger {
-	sugarLogger.Sync()
-	optionTable[OptionLogLevel] = level
-	sugarLogger = initLogger(optionTable[OptionZapOptions].([]zap.Option)...)
-	return sugarLogger
+	return defaultLogger.ChangeLogLevel(level)
 }
 
-func Close() {
-	sugarLogger.Sync()
+func TemporaryLevel(level LogLevel_e, d time.Duration) func() {
+	return defaultLogger.TemporaryLevel(level, d)
 }
 
-func AddWriter(w io.Writer) (*zap.SugaredLogger, string) {
-	uid := uuid.Must(uuid.NewRandom())
-	writerList = append(writerList, writerInfo_t{
-		uid:    uid.String(),
-		writer: w,
-	})
-	sugarLogger = initLogger(optionTable[OptionZapOptions].([]zap.Option)...)
-	return sugarLogger, uid.String()
+func Enabled(level LogLevel_e) bool {
+	return defaultLogger.Enabled(level)
 }
 
-func RemoveWriter(uid string) *zap.SugaredLogger {
-	for i, w := range writerList {
-		if w.uid == uid {
-			writerList = append(writerList[:i], writerList[i+1:]...)
-		}
-	}
-	sugarLogger = initLogger(optionTable[OptionZapOptions].([]zap.Option)...)
-	return sugarLogger
+func AtomicLevel() zap.AtomicLevel {
+	return defaultLogger.AtomicLevel()
 }
 
-func initLogger(options ...zap.Option) *zap.SugaredLogger {
-	encoder := getEncoder()
-	var core zapcore.Core
-	if optionTable[OptionLogLevel] == LogLevelDebug {
-		core = zapcore.NewCore(encoder, getWriter(), zapcore.DebugLevel)
-	} else {
-		core = zapcore.NewCore(encoder, getWriter(), zapcore.InfoLevel)
-	}
+func Rotate() error {
+	return defaultLogger.Rotate()
+}
 
-	return zap.New(core, options...).Sugar()
+func Drain(ctx context.Context) error {
+	return defaultLogger.Drain(ctx)
 }
 
-func getEncoder() zapcore.Encoder {
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = formatEncodeTime
-	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-	return zapcore.NewConsoleEncoder(encoderConfig)
+func Sync() error {
+	return defaultLogger.Sync()
 }
 
-func formatEncodeTime(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-	enc.AppendString(t.Format("2006-01-02 15:04:05"))
+func Close() error {
+	return defaultLogger.Close()
 }
 
-func logWriteInit() {
-	if !optionTable[OptionLogDisableSave].(bool) {
-		lumberJackLogger := &lumberjack.Logger{
-			Filename:   path,
-			MaxSize:    optionTable[OptionLogMaxSize].(int),
-			MaxBackups: optionTable[OptionLogMaxBackup].(int),
-			MaxAge:     optionTable[OptionLogMaxAge].(int),
-			Compress:   optionTable[OptionLogCompress].(bool),
-		}
-		writerList = append(writerList, writerInfo_t{
-			uid:    "",
-			writer: lumberJackLogger,
-		})
-	}
-	writerList = append(writerList, writerInfo_t{
-		uid:    "",
-		writer: os.Stdout,
-	})
+func AddWriter(w io.Writer, level ...LogLevel_e) (*zap.SugaredLogger, string) {
+	return defaultLogger.AddWriter(w, level...)
 }
 
-func getWriter() zapcore.WriteSyncer {
-	wl := []io.Writer{}
-	for _, v := range writerList {
-		wl = append(wl, v.writer)
-	}
-	multiWriter := io.MultiWriter(wl...)
+func AddFileWriter(logPath string, options ...LogOption_t) (*zap.SugaredLogger, string, error) {
+	return defaultLogger.AddFileWriter(logPath, options...)
+}
+
+func AddLabeledWriter(label string, w io.Writer) (*zap.SugaredLogger, string) {
+	return defaultLogger.AddLabeledWriter(label, w)
+}
+
+func AddNetworkWriter(network, address string, options ...LogOption_t) (*zap.SugaredLogger, string, error) {
+	return defaultLogger.AddNetworkWriter(network, address, options...)
+}
+
+func AddHTTPWriter(url string, batchSize int, flushInterval time.Duration) (*zap.SugaredLogger, string, error) {
+	return defaultLogger.AddHTTPWriter(url, batchSize, flushInterval)
+}
+
+func RemoveWriter(uid string) (*zap.SugaredLogger, bool) {
+	return defaultLogger.RemoveWriter(uid)
+}
+
+func SetWriters(writers ...io.Writer) []string {
+	return defaultLogger.SetWriters(writers...)
+}
+
+func RemoveAllWriters() *zap.SugaredLogger {
+	return defaultLogger.RemoveAllWriters()
+}
+
+func Writers() []string {
+	return defaultLogger.Writers()
+}
+
+func GetWriter(uid string) (io.Writer, bool) {
+	return defaultLogger.GetWriter(uid)
+}
+
+func WithFields(fields map[string]interface{}) *zap.SugaredLogger {
+	return defaultLogger.WithFields(fields)
+}
+
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	return defaultLogger.FromContext(ctx)
+}
+
+func SetGlobalFields(fields map[string]interface{}) *zap.SugaredLogger {
+	return defaultLogger.SetGlobalFields(fields)
+}
+
+func LogPath() string {
+	return defaultLogger.LogPath()
+}
+
+func Config() Config_t {
+	return defaultLogger.Config()
+}
 
-	return zapcore.AddSync(multiWriter)
+func LogEvery(key string, n int, level LogLevel_e, msg string, args ...interface{}) {
+	defaultLogger.logEvery(key, n, level, msg, args...)
 }
 
-func optionHandler(options ...LogOption_t) {
-	for k := range optionTable {
-		for _, o := range options {
-			if k == o.Option {
-				optionTable[k] = o.Value
-			}
-		}
+func LogOnce(key string, level LogLevel_e, msg string, args ...interface{}) {
+	defaultLogger.logOnce(key, level, msg, args...)
+}
+
+func AddRingBuffer(capacity int) (*zap.SugaredLogger, string, *RingBufferWriter) {
+	return defaultLogger.AddRingBuffer(capacity)
+}
+
+func StdWriter(level LogLevel_e) io.Writer {
+	return defaultLogger.StdWriter(level)
+}
+
+func RedirectStdLog() func() {
+	return defaultLogger.RedirectStdLog()
+}
+
+func SetLevelFromString(s string) error {
+	return defaultLogger.SetLevelFromString(s)
+}
+
+func LogError(level LogLevel_e, err error, msg string) {
+	defaultLogger.logError(level, err, msg)
+}
+
+func WatchConfig(path string) (func(), error) {
+	return defaultLogger.WatchConfig(path)
+}
+
+func TeeLogger(path string, level LogLevel_e) (*zap.SugaredLogger, error) {
+	return defaultLogger.TeeLogger(path, level)
+}
+
+func Named(name string) *zap.SugaredLogger {
+	return defaultLogger.Named(name)
+}
+
+func Clone() *Logger {
+	return defaultLogger.Clone()
+}
+
+func HigherLevel(min LogLevel_e) (*zap.SugaredLogger, error) {
+	return defaultLogger.HigherLevel(min)
+}
+
+func SetFallbackWriter(w io.Writer) *zap.SugaredLogger {
+	return defaultLogger.SetFallbackWriter(w)
+}
+
+func WithScope(fields map[string]interface{}, fn func(l *zap.SugaredLogger)) {
+	defaultLogger.WithScope(fields, fn)
+}
+
+func BackupFiles() ([]string, error) {
+	return defaultLogger.BackupFiles()
+}
+
+func AddEventLogWriter(source string) (*zap.SugaredLogger, string, error) {
+	return defaultLogger.AddEventLogWriter(source)
+}
+
+func CaptureLogs(fn func()) []string {
+	return defaultLogger.CaptureLogs(fn)
+}
+
+func RecoverAndLog(repanic bool) {
+	if v := recover(); v != nil {
+		defaultLogger.logPanic(v, repanic)
 	}
 }
+
+func InstallSignalFlush(signals ...os.Signal) func() {
+	return defaultLogger.InstallSignalFlush(signals...)
+}
+
+func InstallSignalDrain(signals ...os.Signal) func() {
+	return defaultLogger.InstallSignalDrain(signals...)
+}
+
+func UpdateRotation(options ...LogOption_t) error {
+	return defaultLogger.UpdateRotation(options...)
+}
+
+func Fatal(msg string, args ...interface{}) {
+	defaultLogger.exitWith(false, msg, args...)
+}
+
+func Panic(msg string, args ...interface{}) {
+	defaultLogger.exitWith(true, msg, args...)
+}
+
+func Timed(msg string) func() {
+	return defaultLogger.Timed(msg)
+}
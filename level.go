@@ -0,0 +1,102 @@
+package zapLog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type tempLevel_t struct {
+	seq      uint64
+	previous LogLevel_e
+	// OptionZapLevel from before, nil when it was not set
+	previousZap interface{}
+	timer       *time.Timer
+}
+
+// switch to level for d and go back to the previous level afterwards, the
+// returned func goes back early. A newer TemporaryLevel replaces a running
+// one but still goes back to the level from before the first of them.
+func (l *Logger) TemporaryLevel(level LogLevel_e, d time.Duration) func() {
+	l.mu.Lock()
+	previous, _ := l.optionTable[OptionLogLevel].(LogLevel_e)
+	previousZap := l.optionTable[OptionZapLevel]
+	if l.tempLevel != nil {
+		previous = l.tempLevel.previous
+		previousZap = l.tempLevel.previousZap
+		l.stopTemporaryLevel()
+	}
+	l.tempLevelSeq++
+	seq := l.tempLevelSeq
+	l.tempLevel = &tempLevel_t{
+		seq:         seq,
+		previous:    previous,
+		previousZap: previousZap,
+		timer: time.AfterFunc(d, func() {
+			l.endTemporaryLevel(seq)
+		}),
+	}
+	notify := l.setLevel(level)
+	l.mu.Unlock()
+
+	notify()
+	return func() {
+		l.endTemporaryLevel(seq)
+	}
+}
+
+func (l *Logger) endTemporaryLevel(seq uint64) {
+	l.mu.Lock()
+	if l.tempLevel == nil || l.tempLevel.seq != seq {
+		l.mu.Unlock()
+		return
+	}
+	previous, previousZap := l.tempLevel.previous, l.tempLevel.previousZap
+	l.stopTemporaryLevel()
+	notify := l.setLevel(previous)
+	// setLevel clears OptionZapLevel, put back the one from before
+	if zapLevel, ok := previousZap.(zapcore.Level); ok {
+		l.optionTable[OptionZapLevel] = zapLevel
+		l.level.SetLevel(zapLevel)
+	}
+	l.mu.Unlock()
+
+	notify()
+}
+
+// the caller must hold l.mu
+func (l *Logger) stopTemporaryLevel() {
+	if l.tempLevel != nil {
+		l.tempLevel.timer.Stop()
+		l.tempLevel = nil
+	}
+}
+
+// level from its name, case insensitive, e.g. from a config file
+func ParseLevel(s string) (LogLevel_e, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LogLevelDebug, nil
+	case "info":
+		return LogLevelInfo, nil
+	case "warn", "warning":
+		return LogLevelWarn, nil
+	case "error":
+		return LogLevelError, nil
+	case "fatal":
+		return LogLevelFatal, nil
+	}
+	return LogLevelInfo, fmt.Errorf("zapLog: unknown log level %q, expected debug, info, warn, error or fatal", s)
+}
+
+// ParseLevel and ChangeLogLevel, the level is unchanged on error
+func (l *Logger) SetLevelFromString(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	l.ChangeLogLevel(level)
+	return nil
+}
@@ -0,0 +1,185 @@
+package zapLog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestOnLevelChange(t *testing.T) {
+	type change struct{ old, level LogLevel_e }
+	var mu sync.Mutex
+	changes := []change{}
+	l, _ := newTestLogger(t, LogOption_t{Option: OptionOnLevelChange, Value: func(old, level LogLevel_e) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, change{old, level})
+	}})
+	l.ChangeLogLevel(LogLevelDebug)
+	l.ChangeLogLevel(LogLevelError)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []change{{LogLevelInfo, LogLevelDebug}, {LogLevelDebug, LogLevelError}}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+}
+
+func TestAtomicLevelFlip(t *testing.T) {
+	l, buf := newTestLogger(t)
+	logger := l.GetLogger()
+	logger.Debug("dropped")
+	l.AtomicLevel().SetLevel(zapcore.DebugLevel)
+	logger.Debug("written")
+	l.AtomicLevel().SetLevel(zapcore.ErrorLevel)
+	logger.Warn("dropped again")
+
+	if lines := buf.lines(); len(lines) != 1 || !strings.Contains(lines[0], "written") {
+		t.Errorf("got %q, want only the entry logged at debug", lines)
+	}
+}
+
+func TestTemporaryLevel(t *testing.T) {
+	l, buf := newTestLogger(t)
+	l.TemporaryLevel(LogLevelDebug, 20*time.Millisecond)
+	l.GetLogger().Debug("during")
+
+	deadline := time.Now().Add(time.Second)
+	for l.Enabled(LogLevelDebug) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	l.GetLogger().Debug("after")
+
+	if lines := buf.lines(); len(lines) != 1 || !strings.Contains(lines[0], "during") {
+		t.Errorf("got %q, want only the entry during the temporary level", lines)
+	}
+	if level := l.Config().LogLevel; level != LogLevelInfo {
+		t.Errorf("level after the duration = %v, want info", level)
+	}
+}
+
+func TestTemporaryLevelKeepsZapLevel(t *testing.T) {
+	l, _ := newTestLogger(t, LogOption_t{Option: OptionZapLevel, Value: zapcore.DPanicLevel})
+	l.TemporaryLevel(LogLevelDebug, time.Hour)
+	// the newer one goes back to the level from before the first
+	revert := l.TemporaryLevel(LogLevelWarn, time.Hour)
+	revert()
+	if level := l.AtomicLevel().Level(); level != zapcore.DPanicLevel {
+		t.Errorf("level after the revert = %v, want dpanic", level)
+	}
+	if zapLevel := l.Config().ZapLevel; zapLevel == nil || *zapLevel != zapcore.DPanicLevel {
+		t.Errorf("OptionZapLevel after the revert = %v, want dpanic", zapLevel)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	l, _ := newTestLogger(t)
+	if l.Enabled(LogLevelDebug) {
+		t.Error("Debug enabled at Info")
+	}
+	if !l.Enabled(LogLevelInfo) || !l.Enabled(LogLevelError) {
+		t.Error("Info or Error disabled at Info")
+	}
+	l.ChangeLogLevel(LogLevelDebug)
+	if !l.Enabled(LogLevelDebug) {
+		t.Error("Debug disabled after ChangeLogLevel(Debug)")
+	}
+	if newLogger().Enabled(LogLevelDebug) {
+		t.Error("Debug enabled before Init")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	valid := map[string]LogLevel_e{
+		"debug":     LogLevelDebug,
+		"INFO":      LogLevelInfo,
+		" Warning ": LogLevelWarn,
+		"warn":      LogLevelWarn,
+		"error":     LogLevelError,
+		"Fatal":     LogLevelFatal,
+	}
+	for s, want := range valid {
+		level, err := ParseLevel(s)
+		if err != nil || level != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", s, level, err, want)
+		}
+	}
+	for _, s := range []string{"", "verbose", "3"} {
+		if _, err := ParseLevel(s); err == nil {
+			t.Errorf("ParseLevel(%q) didn't fail", s)
+		}
+	}
+
+	l, _ := newTestLogger(t)
+	if err := l.SetLevelFromString("verbose"); err == nil || l.Enabled(LogLevelDebug) {
+		t.Errorf("SetLevelFromString(verbose) = %v, level changed", err)
+	}
+	if err := l.SetLevelFromString("debug"); err != nil || !l.Enabled(LogLevelDebug) {
+		t.Errorf("SetLevelFromString(debug) = %v, level unchanged", err)
+	}
+}
+
+func TestZapLevel(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionZapLevel, Value: zapcore.WarnLevel})
+	logger := l.GetLogger()
+	logger.Info("filtered")
+	logger.Warn("kept")
+
+	lines := buf.lines()
+	if len(lines) != 1 || !strings.Contains(lines[0], "kept") {
+		t.Errorf("got %q, want only the warning", lines)
+	}
+	// an explicit level replaces OptionZapLevel
+	l.ChangeLogLevel(LogLevelInfo)
+	logger.Info("after change")
+	if lines := buf.lines(); len(lines) != 2 {
+		t.Errorf("got %q after ChangeLogLevel(Info)", lines)
+	}
+}
+
+func TestLevelFilter(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionLevelFilter, Value: []LogLevel_e{LogLevelDebug, LogLevelError}})
+	own := &syncBuffer_t{}
+	l.AddWriter(own, LogLevelInfo)
+	logger := l.GetLogger()
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	lines := buf.lines()
+	if len(lines) != 2 || !strings.Contains(lines[0], "debug") || !strings.Contains(lines[1], "error") {
+		t.Errorf("got %q, want the debug and error entries", lines)
+	}
+	// writers with their own level keep it
+	if lines := own.lines(); len(lines) != 3 {
+		t.Errorf("writer at info got %q", lines)
+	}
+}
+
+func TestHigherLevel(t *testing.T) {
+	l, buf := newTestLogger(t)
+	noisy, err := l.HigherLevel(LogLevelWarn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noisy.Info("dropped")
+	noisy.Warn("kept")
+	l.GetLogger().Info("main logger")
+
+	lines := buf.lines()
+	if len(lines) != 2 || !strings.Contains(lines[0], "kept") || !strings.Contains(lines[1], "main logger") {
+		t.Errorf("got %q", lines)
+	}
+	l.ChangeLogLevel(LogLevelError)
+	if _, err := l.HigherLevel(LogLevelWarn); err == nil {
+		t.Error("HigherLevel below the logger level didn't fail")
+	}
+	if _, err := newLogger().HigherLevel(LogLevelWarn); err == nil {
+		t.Error("HigherLevel before Init didn't fail")
+	}
+}
@@ -0,0 +1,246 @@
+package zapLog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+// key=value pairs separated by spaces, values with spaces, quotes, "=" or
+// control characters are quoted. Arrays, objects and reflected values are
+// written as json.
+type logfmtEncoder_t struct {
+	config *zapcore.EncoderConfig
+	// pairs added by With
+	buf *buffer.Buffer
+	// prefix of the keys after OpenNamespace, e.g. "request."
+	namespace string
+}
+
+func newLogfmtEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder_t{
+		config: &config,
+		buf:    logfmtPool.Get(),
+	}
+}
+
+func (e *logfmtEncoder_t) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder_t{
+		config:    e.config,
+		buf:       logfmtPool.Get(),
+		namespace: e.namespace,
+	}
+	clone.buf.Write(e.buf.Bytes())
+	return clone
+}
+
+func (e *logfmtEncoder_t) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := logfmtPool.Get()
+	if e.config.TimeKey != "" && e.config.EncodeTime != nil {
+		appendPair(line, e.config.TimeKey, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+			e.config.EncodeTime(ent.Time, enc)
+		}))
+	}
+	if e.config.LevelKey != "" && e.config.EncodeLevel != nil {
+		appendPair(line, e.config.LevelKey, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+			e.config.EncodeLevel(ent.Level, enc)
+		}))
+	}
+	if e.config.NameKey != "" && ent.LoggerName != "" {
+		appendPair(line, e.config.NameKey, ent.LoggerName)
+	}
+	if e.config.CallerKey != "" && ent.Caller.Defined && e.config.EncodeCaller != nil {
+		appendPair(line, e.config.CallerKey, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+			e.config.EncodeCaller(ent.Caller, enc)
+		}))
+	}
+	if e.config.MessageKey != "" {
+		appendPair(line, e.config.MessageKey, ent.Message)
+	}
+	if e.buf.Len() > 0 {
+		appendSeparator(line)
+		line.Write(e.buf.Bytes())
+	}
+	if len(fields) > 0 {
+		enc := e.Clone().(*logfmtEncoder_t)
+		enc.buf.Reset()
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		if enc.buf.Len() > 0 {
+			appendSeparator(line)
+			line.Write(enc.buf.Bytes())
+		}
+		enc.buf.Free()
+	}
+	if e.config.StacktraceKey != "" && ent.Stack != "" {
+		appendPair(line, e.config.StacktraceKey, ent.Stack)
+	}
+	if e.config.LineEnding != "" {
+		line.AppendString(e.config.LineEnding)
+	} else {
+		line.AppendString(zapcore.DefaultLineEnding)
+	}
+	return line, nil
+}
+
+func (e *logfmtEncoder_t) add(key string, value string) {
+	appendPair(e.buf, e.namespace+key, value)
+}
+
+func (e *logfmtEncoder_t) addJSON(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.add(key, string(b))
+	return nil
+}
+
+func (e *logfmtEncoder_t) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddArray(key, arr); err != nil {
+		return err
+	}
+	return e.addJSON(key, m.Fields[key])
+}
+
+func (e *logfmtEncoder_t) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := obj.MarshalLogObject(m); err != nil {
+		return err
+	}
+	return e.addJSON(key, m.Fields)
+}
+
+func (e *logfmtEncoder_t) AddReflected(key string, v interface{}) error {
+	return e.addJSON(key, v)
+}
+
+func (e *logfmtEncoder_t) OpenNamespace(key string) {
+	e.namespace += key + "."
+}
+
+func (e *logfmtEncoder_t) AddBinary(key string, v []byte) {
+	e.add(key, base64.StdEncoding.EncodeToString(v))
+}
+
+func (e *logfmtEncoder_t) AddByteString(key string, v []byte) { e.add(key, string(v)) }
+func (e *logfmtEncoder_t) AddBool(key string, v bool)         { e.add(key, strconv.FormatBool(v)) }
+func (e *logfmtEncoder_t) AddComplex128(key string, v complex128) {
+	e.add(key, strconv.FormatComplex(v, 'g', -1, 128))
+}
+func (e *logfmtEncoder_t) AddComplex64(key string, v complex64) {
+	e.add(key, strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+func (e *logfmtEncoder_t) AddFloat64(key string, v float64) {
+	e.add(key, strconv.FormatFloat(v, 'g', -1, 64))
+}
+func (e *logfmtEncoder_t) AddFloat32(key string, v float32) {
+	e.add(key, strconv.FormatFloat(float64(v), 'g', -1, 32))
+}
+func (e *logfmtEncoder_t) AddInt(key string, v int)         { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder_t) AddInt64(key string, v int64)     { e.add(key, strconv.FormatInt(v, 10)) }
+func (e *logfmtEncoder_t) AddInt32(key string, v int32)     { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder_t) AddInt16(key string, v int16)     { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder_t) AddInt8(key string, v int8)       { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder_t) AddString(key, v string)          { e.add(key, v) }
+func (e *logfmtEncoder_t) AddUint(key string, v uint)       { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder_t) AddUint64(key string, v uint64)   { e.add(key, strconv.FormatUint(v, 10)) }
+func (e *logfmtEncoder_t) AddUint32(key string, v uint32)   { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder_t) AddUint16(key string, v uint16)   { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder_t) AddUint8(key string, v uint8)     { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder_t) AddUintptr(key string, v uintptr) { e.AddUint64(key, uint64(v)) }
+
+func (e *logfmtEncoder_t) AddDuration(key string, v time.Duration) {
+	if e.config.EncodeDuration == nil {
+		e.add(key, v.String())
+		return
+	}
+	e.add(key, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+		e.config.EncodeDuration(v, enc)
+	}))
+}
+
+func (e *logfmtEncoder_t) AddTime(key string, v time.Time) {
+	if e.config.EncodeTime == nil {
+		e.add(key, v.Format(time.RFC3339Nano))
+		return
+	}
+	e.add(key, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+		e.config.EncodeTime(v, enc)
+	}))
+}
+
+func appendSeparator(buf *buffer.Buffer) {
+	if buf.Len() > 0 {
+		buf.AppendByte(' ')
+	}
+}
+
+func appendPair(buf *buffer.Buffer, key string, value string) {
+	appendSeparator(buf)
+	buf.AppendString(key)
+	buf.AppendByte('=')
+	if logfmtNeedsQuote(value) {
+		buf.AppendString(strconv.Quote(value))
+		return
+	}
+	buf.AppendString(value)
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// the values the zapcore.EncoderConfig funcs append, joined by spaces
+func encodePrimitive(encode func(zapcore.PrimitiveArrayEncoder)) string {
+	enc := &primitiveEncoder_t{}
+	encode(enc)
+	return strings.Join(enc.values, " ")
+}
+
+type primitiveEncoder_t struct {
+	values []string
+}
+
+func (p *primitiveEncoder_t) append(v string) { p.values = append(p.values, v) }
+
+func (p *primitiveEncoder_t) AppendBool(v bool)             { p.append(strconv.FormatBool(v)) }
+func (p *primitiveEncoder_t) AppendByteString(v []byte)     { p.append(string(v)) }
+func (p *primitiveEncoder_t) AppendComplex128(v complex128) { p.append(fmt.Sprint(v)) }
+func (p *primitiveEncoder_t) AppendComplex64(v complex64)   { p.append(fmt.Sprint(v)) }
+func (p *primitiveEncoder_t) AppendFloat64(v float64)       { p.append(strconv.FormatFloat(v, 'g', -1, 64)) }
+func (p *primitiveEncoder_t) AppendFloat32(v float32) {
+	p.append(strconv.FormatFloat(float64(v), 'g', -1, 32))
+}
+func (p *primitiveEncoder_t) AppendInt(v int)         { p.append(strconv.Itoa(v)) }
+func (p *primitiveEncoder_t) AppendInt64(v int64)     { p.append(strconv.FormatInt(v, 10)) }
+func (p *primitiveEncoder_t) AppendInt32(v int32)     { p.AppendInt64(int64(v)) }
+func (p *primitiveEncoder_t) AppendInt16(v int16)     { p.AppendInt64(int64(v)) }
+func (p *primitiveEncoder_t) AppendInt8(v int8)       { p.AppendInt64(int64(v)) }
+func (p *primitiveEncoder_t) AppendString(v string)   { p.append(v) }
+func (p *primitiveEncoder_t) AppendUint(v uint)       { p.AppendUint64(uint64(v)) }
+func (p *primitiveEncoder_t) AppendUint64(v uint64)   { p.append(strconv.FormatUint(v, 10)) }
+func (p *primitiveEncoder_t) AppendUint32(v uint32)   { p.AppendUint64(uint64(v)) }
+func (p *primitiveEncoder_t) AppendUint16(v uint16)   { p.AppendUint64(uint64(v)) }
+func (p *primitiveEncoder_t) AppendUint8(v uint8)     { p.AppendUint64(uint64(v)) }
+func (p *primitiveEncoder_t) AppendUintptr(v uintptr) { p.AppendUint64(uint64(v)) }
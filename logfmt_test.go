@@ -0,0 +1,27 @@
+package zapLog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogfmtQuoting(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatLogfmt},
+		LogOption_t{Option: OptionDisableTimestamp, Value: true})
+	l.GetLogger().Infow("user login", "user", "bob", "agent", "curl 8.0", "query", "a=b", "empty", "")
+
+	line := buf.lines()[0]
+	for _, pair := range []string{
+		`level=INFO`,
+		`msg="user login"`,
+		`user=bob`,
+		`agent="curl 8.0"`,
+		`query="a=b"`,
+		`empty=""`,
+	} {
+		if !strings.Contains(line, pair) {
+			t.Errorf("%q not in %q", pair, line)
+		}
+	}
+}
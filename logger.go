@@ -0,0 +1,1201 @@
+package zapLog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger holds its own file, options and writers, so several of them
+// can live side by side in one application.
+type Logger struct {
+	mu          sync.RWMutex
+	path        string
+	optionTable map[OptionType_e]interface{}
+	writerList  []writerInfo_t
+	sugarLogger *zap.SugaredLogger
+	coreLogger  *zap.Logger
+	// same entries limited to the file or the console writers
+	fileOnlyLogger    *zap.SugaredLogger
+	consoleOnlyLogger *zap.SugaredLogger
+	// follows OptionLogLevel, shared by the cores so level changes need no rebuild
+	level zap.AtomicLevel
+	// nil when OptionLogDisableSave is set
+	fileWriter *fileWriter_t
+	// nil unless OptionBufferSize is set, kept across rebuilds so the
+	// loggers handed out earlier keep a flushed buffer
+	bufferedWriter *zapcore.BufferedWriteSyncer
+	bufferTarget   *swapWriteSyncer_t
+	// buffers replaced by an Init with other buffer options, loggers handed
+	// out before may still write to them
+	stoppedBuffers []*zapcore.BufferedWriteSyncer
+	// nil unless created by NewRoutingLogger
+	router *router_t
+	// files of the TeeLogger loggers
+	teeWriters []*fileWriter_t
+	// SetFallbackWriter, kept across Init
+	fallback zapcore.WriteSyncer
+	// set by Drain, checked by every entry
+	draining int32
+	// attached to every entry, kept across rebuilds
+	globalFields []zap.Field
+	// os.Hostname for OptionIncludeHost, looked up once by Init
+	host string
+	// nil unless a TemporaryLevel is running
+	tempLevel    *tempLevel_t
+	tempLevelSeq uint64
+	// call counts of LogEvery and keys seen by LogOnce
+	throttleMu sync.Mutex
+	throttle   map[string]uint64
+	once       map[string]struct{}
+}
+
+func newLogger() *Logger {
+	return &Logger{
+		optionTable: newOptionTable(),
+		writerList:  []writerInfo_t{},
+		level:       zap.NewAtomicLevelAt(zapcore.InfoLevel),
+		throttle:    map[string]uint64{},
+		once:        map[string]struct{}{},
+	}
+}
+
+func NewLogger(logPath string, options ...LogOption_t) (*Logger, error) {
+	l := newLogger()
+	if err := l.init(logPath, options...); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Logger) init(logPath string, options ...LogOption_t) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.optionHandler(options...); err != nil {
+		return err
+	}
+	discard := l.optionTable[OptionDiscard].(bool)
+	if !discard && !l.optionTable[OptionLogDisableSave].(bool) {
+		if err := checkLogPath(logPath); err != nil {
+			return err
+		}
+	}
+	if err := checkFileMode(l.optionTable); err != nil {
+		return err
+	}
+	l.path = logPath
+	atomic.StoreInt32(&l.draining, 0)
+	if l.optionTable[OptionIncludeHost].(bool) && l.host == "" {
+		l.host, _ = osHostname()
+	}
+	if level, ok := l.optionTable[OptionZapLevel].(zapcore.Level); ok {
+		l.level.SetLevel(level)
+	} else {
+		l.level.SetLevel(getZapLevel(l.optionTable[OptionLogLevel]))
+	}
+	l.removeInternalWriters()
+	if !discard {
+		if err := l.logWriteInit(); err != nil {
+			return err
+		}
+	}
+	l.initLogger()
+	return nil
+}
+
+// independent Logger with the options, level, global fields and writers of
+// l, changing the level or options of one doesn't affect the other.
+// The writers are shared, not duplicated: both write to the same file and
+// user writers, a rotation or UpdateRotation applies to both, and only one
+// of them should be closed. RemoveWriter on the clone doesn't close the
+// shared writers. Routed files and TeeLogger files are not copied.
+func (l *Logger) Clone() *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	c := newLogger()
+	for k, v := range l.optionTable {
+		c.optionTable[k] = v
+	}
+	c.path = l.path
+	c.level.SetLevel(l.level.Level())
+	for _, w := range l.writerList {
+		w.owned = false
+		c.writerList = append(c.writerList, w)
+	}
+	c.fileWriter = l.fileWriter
+	c.globalFields = append([]zap.Field{}, l.globalFields...)
+	c.host = l.host
+	c.initLogger()
+	return c
+}
+
+// logger dropping every entry without touching files or stdout, for tests
+func NewNop() *Logger {
+	l, _ := NewLogger("", LogOption_t{Option: OptionDiscard, Value: true})
+	return l
+}
+
+// create the parent directory and make sure the file can be opened for writing,
+// lumberjack itself only reports this on the first write
+func checkLogPath(logPath string) error {
+	if logPath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("zapLog: create log directory: %w", err)
+	}
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("zapLog: log path is not writable: %w", err)
+	}
+	return f.Close()
+}
+
+// nil before Init
+func (l *Logger) GetLogger() *zap.SugaredLogger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.sugarLogger
+}
+
+// logger writing only to the log file, e.g. for verbose diagnostics
+func (l *Logger) FileOnly() *zap.SugaredLogger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.fileOnlyLogger
+}
+
+// logger writing only to stdout (and stderr with OptionSplitStderr)
+func (l *Logger) ConsoleOnly() *zap.SugaredLogger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.consoleOnlyLogger
+}
+
+// path of the log file, empty when nothing is saved
+func (l *Logger) LogPath() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.fileWriter == nil {
+		return ""
+	}
+	return l.path
+}
+
+// the non-sugared logger for allocation free logging
+func (l *Logger) GetCoreLogger() *zap.Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.coreLogger
+}
+
+func (l *Logger) ChangeLogLevel(level LogLevel_e) *zap.SugaredLogger {
+	l.mu.Lock()
+	// an explicit level replaces a pending TemporaryLevel
+	l.stopTemporaryLevel()
+	notify := l.setLevel(level)
+	sugarLogger := l.sugarLogger
+	l.mu.Unlock()
+
+	// outside the lock so the callback can use the logger
+	notify()
+	return sugarLogger
+}
+
+// the caller must hold l.mu and call the returned func after unlocking
+func (l *Logger) setLevel(level LogLevel_e) func() {
+	old, _ := l.optionTable[OptionLogLevel].(LogLevel_e)
+	onLevelChange, _ := l.optionTable[OptionOnLevelChange].(func(LogLevel_e, LogLevel_e))
+	l.optionTable[OptionLogLevel] = level
+	// an explicit level replaces OptionZapLevel
+	l.optionTable[OptionZapLevel] = nil
+	l.level.SetLevel(getZapLevel(level))
+	return func() {
+		if onLevelChange != nil {
+			onLevelChange(old, level)
+		}
+	}
+}
+
+// whether an entry at level would be written by any writer
+func (l *Logger) Enabled(level LogLevel_e) bool {
+	coreLogger := l.GetCoreLogger()
+	if coreLogger == nil {
+		return l.level.Enabled(getZapLevel(level))
+	}
+	return coreLogger.Core().Enabled(getZapLevel(level))
+}
+
+// the level used by the logger cores, it can be served over http with
+// ServeHTTP, levels set on it directly are not reflected in Config
+func (l *Logger) AtomicLevel() zap.AtomicLevel {
+	return l.level
+}
+
+// start a new log file now, the current one is kept as a backup
+func (l *Logger) Rotate() error {
+	l.mu.RLock()
+	fileWriter := l.fileWriter
+	l.mu.RUnlock()
+	if fileWriter == nil {
+		return ErrSaveDisabled
+	}
+	return fileWriter.Rotate()
+}
+
+// rotated files of the log file, oldest first by modification time, e.g.
+// to offer them for download
+func (l *Logger) BackupFiles() ([]string, error) {
+	l.mu.RLock()
+	fileWriter := l.fileWriter
+	l.mu.RUnlock()
+	if fileWriter == nil {
+		return nil, ErrSaveDisabled
+	}
+	return sortByModTime(backupFiles(fileWriter.filename())), nil
+}
+
+// flush buffered entries, the logger stays usable
+func (l *Logger) Sync() error {
+	l.mu.RLock()
+	sugarLogger := l.sugarLogger
+	err := l.syncStoppedBuffers()
+	l.mu.RUnlock()
+	if sugarLogger == nil {
+		return err
+	}
+	return multierr.Append(err, sugarLogger.Sync())
+}
+
+// flush the logger and then flush and close every writer that supports it,
+// stdout and stderr stay open
+func (l *Logger) Close() error {
+	err := l.Sync()
+	l.mu.Lock()
+	l.stopBufferedWriter()
+	err = multierr.Append(err, l.syncStoppedBuffers())
+	l.stoppedBuffers = nil
+	router := l.router
+	teeWriters := l.teeWriters
+	l.teeWriters = nil
+	writerList := append([]writerInfo_t{}, l.writerList...)
+	// closing can wait for a slow writer, the logger stays usable meanwhile
+	l.mu.Unlock()
+
+	if router != nil {
+		err = multierr.Append(err, router.close())
+	}
+	for _, w := range teeWriters {
+		err = multierr.Append(err, w.Close())
+	}
+	for _, w := range writerList {
+		if w.writer == os.Stdout || w.writer == os.Stderr {
+			continue
+		}
+		if f, ok := w.writer.(interface{ Flush() error }); ok {
+			err = multierr.Append(err, f.Flush())
+		}
+		if c, ok := w.writer.(io.Closer); ok {
+			err = multierr.Append(err, c.Close())
+		}
+	}
+	return err
+}
+
+// Close that gives up when ctx is done, e.g. when an http writer can't reach
+// its endpoint during shutdown. The writers keep closing in the background.
+// Entries logged after Drain started are dropped until the next Init.
+func (l *Logger) Drain(ctx context.Context) error {
+	atomic.StoreInt32(&l.draining, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Close()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("zapLog: drain: %w", ctx.Err())
+	}
+}
+
+// the writer follows the logger level unless its own level is given
+func (l *Logger) AddWriter(w io.Writer, level ...LogLevel_e) (*zap.SugaredLogger, string) {
+	info := writerInfo_t{
+		writer: w,
+	}
+	if len(level) > 0 {
+		info.level = &level[0]
+	}
+	return l.addWriter(info)
+}
+
+func (l *Logger) addWriter(info writerInfo_t) (*zap.SugaredLogger, string) {
+	info.uid = uuid.Must(uuid.NewRandom()).String()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writerList = append(l.writerList, info)
+	l.initLogger()
+	return l.sugarLogger, info.uid
+}
+
+func (l *Logger) RemoveWriter(uid string) (*zap.SugaredLogger, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	removed := false
+	wl := []writerInfo_t{}
+	for _, w := range l.writerList {
+		if w.kind == writerKindUser && w.uid == uid {
+			removed = true
+			if c, ok := w.writer.(io.Closer); ok && w.owned {
+				c.Close()
+			}
+			continue
+		}
+		wl = append(wl, w)
+	}
+	l.writerList = wl
+	l.initLogger()
+	return l.sugarLogger, removed
+}
+
+// remove every writer added by the Add*Writer functions, the file and
+// stdout writers stay
+func (l *Logger) RemoveAllWriters() *zap.SugaredLogger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	wl := []writerInfo_t{}
+	for _, w := range l.writerList {
+		if w.kind != writerKindUser {
+			wl = append(wl, w)
+			continue
+		}
+		if c, ok := w.writer.(io.Closer); ok && w.owned {
+			c.Close()
+		}
+	}
+	l.writerList = wl
+	l.initLogger()
+	return l.sugarLogger
+}
+
+// replace the writers added by the Add*Writer functions with writers, the
+// logger is rebuilt once. The file and stdout writers stay.
+func (l *Logger) SetWriters(writers ...io.Writer) []string {
+	infos := make([]writerInfo_t, 0, len(writers))
+	uids := make([]string, 0, len(writers))
+	for _, w := range writers {
+		uid := uuid.Must(uuid.NewRandom()).String()
+		infos = append(infos, writerInfo_t{uid: uid, writer: w})
+		uids = append(uids, uid)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	wl := []writerInfo_t{}
+	for _, w := range l.writerList {
+		if w.kind != writerKindUser {
+			wl = append(wl, w)
+			continue
+		}
+		if c, ok := w.writer.(io.Closer); ok && w.owned {
+			c.Close()
+		}
+	}
+	l.writerList = append(wl, infos...)
+	l.initLogger()
+	return uids
+}
+
+// add a writer that only gets the entries tagged with LogTo(label), those
+// entries skip the other writers
+func (l *Logger) AddLabeledWriter(label string, w io.Writer) (*zap.SugaredLogger, string) {
+	return l.addWriter(writerInfo_t{
+		writer: w,
+		label:  label,
+	})
+}
+
+// add a rotated file with its own rotation options, only the rotation
+// options (OptionLogMaxSize, OptionLogMaxBackup, OptionLogMaxAge,
+// OptionLogCompress, OptionLogLocalTime, OptionRotateHook, OptionOnRotate,
+// OptionFileMode) and OptionDualEncode are used
+func (l *Logger) AddFileWriter(logPath string, options ...LogOption_t) (*zap.SugaredLogger, string, error) {
+	optionTable := newOptionTable()
+	if err := setOptions(optionTable, options...); err != nil {
+		return nil, "", err
+	}
+	if err := checkFileMode(optionTable); err != nil {
+		return nil, "", err
+	}
+	if err := checkLogPath(logPath); err != nil {
+		return nil, "", err
+	}
+	fileWriter, err := newRotateFileWriter(logPath, optionTable)
+	if err != nil {
+		return nil, "", err
+	}
+	sugarLogger, uid := l.addWriter(writerInfo_t{
+		writer:     fileWriter,
+		owned:      true,
+		dualEncode: optionTable[OptionDualEncode].(bool),
+	})
+	return sugarLogger, uid, nil
+}
+
+// add a tcp or udp connection, the connection is dialed again in the
+// background when a write fails and the entries logged meanwhile are
+// dropped. Only OptionLogCompress is used, it gzips the stream.
+func (l *Logger) AddNetworkWriter(network, address string, options ...LogOption_t) (*zap.SugaredLogger, string, error) {
+	optionTable := newOptionTable()
+	if err := setOptions(optionTable, options...); err != nil {
+		return nil, "", err
+	}
+	w, err := newNetWriter(network, address, optionTable[OptionLogCompress].(bool))
+	if err != nil {
+		return nil, "", err
+	}
+	sugarLogger, uid := l.addWriter(writerInfo_t{
+		writer: w,
+		owned:  true,
+	})
+	return sugarLogger, uid, nil
+}
+
+// POST the entries as a json array once batchSize entries are collected or
+// flushInterval passed, failed requests are retried in the background
+func (l *Logger) AddHTTPWriter(url string, batchSize int, flushInterval time.Duration) (*zap.SugaredLogger, string, error) {
+	w, err := newHTTPWriter(url, batchSize, flushInterval)
+	if err != nil {
+		return nil, "", err
+	}
+	sugarLogger, uid := l.addWriter(writerInfo_t{
+		writer: w,
+		owned:  true,
+	})
+	return sugarLogger, uid, nil
+}
+
+// entries the log file fails to write, e.g. when the disk is full, go to w
+// instead, nil removes it. The failure is still reported to
+// OptionInternalErrorWriter.
+func (l *Logger) SetFallbackWriter(w io.Writer) *zap.SugaredLogger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fallback = nil
+	if w != nil {
+		// the file only and main loggers write to it concurrently
+		l.fallback = zapcore.Lock(zapcore.AddSync(w))
+	}
+	for i := range l.writerList {
+		if l.writerList[i].kind == writerKindFile {
+			l.writerList[i].fallback = l.fallback
+		}
+	}
+	l.initLogger()
+	return l.sugarLogger
+}
+
+// uids of the writers added by AddWriter
+func (l *Logger) Writers() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	uids := []string{}
+	for _, w := range l.writerList {
+		if w.kind == writerKindUser {
+			uids = append(uids, w.uid)
+		}
+	}
+	return uids
+}
+
+// writer registered by AddWriter with the given uid
+func (l *Logger) GetWriter(uid string) (io.Writer, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, w := range l.writerList {
+		if w.kind == writerKindUser && w.uid == uid {
+			return w.writer, true
+		}
+	}
+	return nil, false
+}
+
+// logger with name added to its name, nested names are joined with dots,
+// e.g. "http.client", nil before Init
+func (l *Logger) Named(name string) *zap.SugaredLogger {
+	sugarLogger := l.GetLogger()
+	if sugarLogger == nil {
+		return nil
+	}
+	return sugarLogger.Named(name)
+}
+
+// logger derived from the current one which only writes min and above, e.g.
+// for a noisy subsystem. min below the current level is an error, the derived
+// logger can't write what the logger itself drops.
+func (l *Logger) HigherLevel(min LogLevel_e) (*zap.SugaredLogger, error) {
+	coreLogger := l.GetCoreLogger()
+	if coreLogger == nil {
+		return nil, errors.New("zapLog: logger is not initialized")
+	}
+	core, err := zapcore.NewIncreaseLevelCore(coreLogger.Core(), getZapLevel(min))
+	if err != nil {
+		return nil, fmt.Errorf("zapLog: higher level: %w", err)
+	}
+	return coreLogger.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return core
+	})).Sugar(), nil
+}
+
+// logger derived from the current one with the given fields attached
+func (l *Logger) WithFields(fields map[string]interface{}) *zap.SugaredLogger {
+	args := []interface{}{}
+	for _, f := range mapToFields(fields) {
+		args = append(args, f)
+	}
+	sugarLogger := l.GetLogger()
+	if sugarLogger == nil {
+		return nil
+	}
+	return sugarLogger.With(args...)
+}
+
+// run fn with a logger carrying fields, e.g. the request id in a handler.
+// Before Init fn gets a logger which drops everything.
+func (l *Logger) WithScope(fields map[string]interface{}, fn func(l *zap.SugaredLogger)) {
+	sugarLogger := l.WithFields(fields)
+	if sugarLogger == nil {
+		sugarLogger = zap.NewNop().Sugar()
+	}
+	fn(sugarLogger)
+}
+
+// logger with the trace_id and span_id found by OptionTraceExtractor,
+// the base logger when the context carries no trace
+func (l *Logger) FromContext(ctx context.Context) *zap.SugaredLogger {
+	l.mu.RLock()
+	extractor, _ := l.optionTable[OptionTraceExtractor].(func(context.Context) (string, string))
+	sugarLogger := l.sugarLogger
+	l.mu.RUnlock()
+	if extractor == nil || ctx == nil || sugarLogger == nil {
+		return sugarLogger
+	}
+	traceID, spanID := extractor(ctx)
+	args := []interface{}{}
+	if traceID != "" {
+		args = append(args, zap.String("trace_id", traceID))
+	}
+	if spanID != "" {
+		args = append(args, zap.String("span_id", spanID))
+	}
+	if len(args) == 0 {
+		return sugarLogger
+	}
+	return sugarLogger.With(args...)
+}
+
+// replace the fields attached to every entry of this logger
+func (l *Logger) SetGlobalFields(fields map[string]interface{}) *zap.SugaredLogger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.globalFields = mapToFields(fields)
+	l.initLogger()
+	return l.sugarLogger
+}
+
+// sorted by key so the output order is stable
+func mapToFields(fields map[string]interface{}) []zap.Field {
+	keys := []string{}
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	zapFields := []zap.Field{}
+	for _, k := range keys {
+		zapFields = append(zapFields, zap.Any(k, fields[k]))
+	}
+	return zapFields
+}
+
+// the caller must hold l.mu
+func (l *Logger) initLogger() {
+	if l.optionTable[OptionDiscard].(bool) {
+		l.stopBufferedWriter()
+		l.coreLogger = zap.NewNop()
+		l.sugarLogger = l.coreLogger.Sugar()
+		l.fileOnlyLogger = l.sugarLogger
+		l.consoleOnlyLogger = l.sugarLogger
+		return
+	}
+
+	l.coreLogger = zap.New(l.buildCore(l.writerList, true, true), l.getZapOptions()...)
+	l.sugarLogger = l.coreLogger.Sugar()
+	l.fileOnlyLogger = zap.New(l.buildCore(l.writersOfKind(writerKindFile), false, false), l.getZapOptions()...).Sugar()
+	l.consoleOnlyLogger = zap.New(l.buildCore(l.writersOfKind(writerKindConsole), false, true), l.getZapOptions()...).Sugar()
+}
+
+// the caller must hold l.mu, only the main core gets the buffer and the
+// routed files
+func (l *Logger) buildCore(writerList []writerInfo_t, main bool, console bool) zapcore.Core {
+	encoder := l.getEncoder()
+	// writers without their own level or encoder share one core, entry
+	// writers always get their own so the buffer doesn't join entries
+	sharedWriters := []writerInfo_t{}
+	cores := []zapcore.Core{}
+	labeledCores := map[string]zapcore.Core{}
+	for _, w := range writerList {
+		writerEncoder := l.getWriterEncoder(w)
+		if w.label != "" {
+			core := zapcore.NewCore(encoder, l.syncWriter(getWriter([]writerInfo_t{w})), l.writerLevel(w))
+			if labeled, ok := labeledCores[w.label]; ok {
+				core = newTeeCore(labeled, core)
+			}
+			labeledCores[w.label] = core
+			continue
+		}
+		if w.dualEncode {
+			ws := l.syncWriter(getWriter([]writerInfo_t{w}))
+			cores = append(cores, newDualEncodeCore(l.newEncoder(EncoderFormatConsole), l.newEncoder(EncoderFormatJson), ws, l.writerLevel(w)))
+			continue
+		}
+		if lw, ok := w.writer.(levelWriter_i); ok {
+			// needs the level of every entry, e.g. the windows event log
+			if writerEncoder == nil {
+				writerEncoder = encoder
+			}
+			cores = append(cores, newLevelWriterCore(writerEncoder, lw, l.writerLevel(w)))
+			continue
+		}
+		if _, ok := w.writer.(entryWriter_i); !ok && w.level == nil && writerEncoder == nil {
+			sharedWriters = append(sharedWriters, w)
+			continue
+		}
+		if writerEncoder == nil {
+			writerEncoder = encoder
+		}
+		cores = append(cores, zapcore.NewCore(writerEncoder, l.syncWriter(getWriter([]writerInfo_t{w})), l.writerLevel(w)))
+	}
+	sharedWriter := getWriter(sharedWriters)
+	if main {
+		sharedWriter = l.bufferWriter(sharedWriter)
+	}
+	level := l.levelEnabler()
+	cores = append(cores, zapcore.NewCore(encoder, l.syncWriter(sharedWriter), level))
+	if main && l.router != nil {
+		routeEncoder := l.getKindEncoder(writerKindFile)
+		if routeEncoder == nil {
+			routeEncoder = encoder
+		}
+		l.router.setOptionTable(l.optionTable)
+		cores = append(cores, newRoutingCore(l.router, routeEncoder, level))
+	}
+	if console && l.splitStderr() {
+		consoleEncoder := l.getKindEncoder(writerKindConsole)
+		if consoleEncoder == nil {
+			consoleEncoder = encoder
+		}
+		cores = append(cores, splitConsoleCores(consoleEncoder, level)...)
+	}
+
+	core := newTeeCore(cores...)
+	if len(labeledCores) > 0 {
+		core = newLabelCore(core, labeledCores)
+	}
+	if keys := l.optionTable[OptionRedactKeys].([]string); len(keys) > 0 {
+		// below the global fields so they are redacted too
+		core = newRedactCore(core, keys)
+	}
+	if maxBytes := l.optionTable[OptionMaxMessageBytes].(int); maxBytes > 0 {
+		core = newTruncateCore(core, maxBytes)
+	}
+	core = core.With(l.globalFields)
+	if l.optionTable[OptionGoroutineID].(bool) {
+		core = goroutineCore_t{core}
+	}
+	if sampler, ok := l.optionTable[OptionFieldSampler].(FieldSampler_t); ok {
+		// the fields of the entry are only known in Write, zap's sampler
+		// decides in Check so it has to wrap this one
+		core = newFieldSamplerCore(core, sampler)
+	}
+	if sampling, ok := l.optionTable[OptionSampling].(Sampling_t); ok {
+		core = newSamplerCore(core, sampling)
+	}
+	if window := l.optionTable[OptionDedupWindow].(time.Duration); window > 0 {
+		core = newDedupCore(core, window)
+	}
+	return drainCore_t{Core: core, draining: &l.draining}
+}
+
+// OptionLevelFilter when set, the atomic level otherwise
+func (l *Logger) levelEnabler() zapcore.LevelEnabler {
+	levels, ok := l.optionTable[OptionLevelFilter].([]LogLevel_e)
+	if !ok {
+		return l.level
+	}
+	enabled := map[zapcore.Level]bool{}
+	for _, level := range levels {
+		enabled[getZapLevel(level)] = true
+	}
+	return zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return enabled[lvl]
+	})
+}
+
+func (l *Logger) writerLevel(w writerInfo_t) zapcore.LevelEnabler {
+	if w.level != nil {
+		return getZapLevel(*w.level)
+	}
+	return l.levelEnabler()
+}
+
+// the caller must hold l.mu
+func (l *Logger) writersOfKind(kind writerKind_e) []writerInfo_t {
+	wl := []writerInfo_t{}
+	for _, w := range l.writerList {
+		if w.kind == kind {
+			wl = append(wl, w)
+		}
+	}
+	return wl
+}
+
+// OptionZapOptions plus the zap options derived from the option table
+func (l *Logger) getZapOptions() []zap.Option {
+	options := append([]zap.Option{}, l.optionTable[OptionZapOptions].([]zap.Option)...)
+	development := l.optionTable[OptionDevelopment].(bool)
+	if l.optionTable[OptionAddCaller].(bool) || development {
+		// the sugared logger reports the line that called it, every extra
+		// wrapper function between the caller and the sugared logger needs
+		// one more skip
+		options = append(options, zap.AddCaller(), zap.AddCallerSkip(l.optionTable[OptionCallerSkip].(int)))
+	}
+	if development {
+		options = append(options, zap.Development())
+	}
+	if level, ok := l.optionTable[OptionStacktraceLevel].(LogLevel_e); ok {
+		options = append(options, zap.AddStacktrace(getZapLevel(level)))
+	} else if development {
+		// same as zap.NewDevelopmentConfig
+		options = append(options, zap.AddStacktrace(zapcore.WarnLevel))
+	}
+	if hooks := l.optionTable[OptionHooks].([]func(zapcore.Entry) error); len(hooks) > 0 {
+		options = append(options, zap.Hooks(hooks...))
+	}
+	if fields := l.initialFields(); len(fields) > 0 {
+		options = append(options, zap.Fields(fields...))
+	}
+	if w, ok := l.optionTable[OptionInternalErrorWriter].(io.Writer); ok && w != nil {
+		options = append(options, zap.ErrorOutput(zapcore.Lock(zapcore.AddSync(w))))
+	}
+	return options
+}
+
+// OptionInitialFields, OptionIncludeHost and OptionIncludePID
+func (l *Logger) initialFields() []zap.Field {
+	fields := mapToFields(l.optionTable[OptionInitialFields].(map[string]interface{}))
+	if l.optionTable[OptionIncludeHost].(bool) && l.host != "" {
+		fields = append(fields, zap.String("host", l.host))
+	}
+	if l.optionTable[OptionIncludePID].(bool) {
+		fields = append(fields, zap.Int("pid", os.Getpid()))
+	}
+	return fields
+}
+
+// replaced in tests
+var osHostname = os.Hostname
+
+// unknown level falls back to info
+func getZapLevel(level interface{}) zapcore.Level {
+	switch level {
+	case LogLevelDebug:
+		return zapcore.DebugLevel
+	case LogLevelInfo:
+		return zapcore.InfoLevel
+	case LogLevelWarn:
+		return zapcore.WarnLevel
+	case LogLevelError:
+		return zapcore.ErrorLevel
+	case LogLevelFatal:
+		return zapcore.FatalLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+func (l *Logger) getEncoder() zapcore.Encoder {
+	if encoder, ok := l.optionTable[OptionCustomEncoder].(zapcore.Encoder); ok && encoder != nil {
+		return l.prefixEncoder(encoder.Clone())
+	}
+	return l.newEncoder(l.optionTable[OptionEncoderFormat].(EncoderFormat_e))
+}
+
+// encoder for writers which don't use the default one, nil for the default
+func (l *Logger) getWriterEncoder(w writerInfo_t) zapcore.Encoder {
+	return l.getKindEncoder(w.kind)
+}
+
+// OptionConsoleEncoder and OptionFileEncoder, then OptionConsoleHumanFileJSON,
+// nil for the default encoder
+func (l *Logger) getKindEncoder(kind writerKind_e) zapcore.Encoder {
+	humanJSON := l.optionTable[OptionConsoleHumanFileJSON].(bool)
+	switch kind {
+	case writerKindFile:
+		if format, ok := l.optionTable[OptionFileEncoder].(EncoderFormat_e); ok {
+			return l.newEncoder(format)
+		}
+		if humanJSON {
+			return l.newEncoder(EncoderFormatJson)
+		}
+	case writerKindConsole:
+		if format, ok := l.optionTable[OptionConsoleEncoder].(EncoderFormat_e); ok {
+			return l.newEncoder(format)
+		}
+		if humanJSON {
+			return l.newEncoder(EncoderFormatConsole)
+		}
+	}
+	return nil
+}
+
+func (l *Logger) newEncoder(format EncoderFormat_e) zapcore.Encoder {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	development := l.optionTable[OptionDevelopment].(bool)
+	if development {
+		encoderConfig = zap.NewDevelopmentEncoderConfig()
+	}
+	location, _ := l.optionTable[OptionTimeZone].(*time.Location)
+	layout := l.optionTable[OptionTimeFormat].(string)
+	precision := l.optionTable[OptionTimePrecision].(TimePrecision_e)
+	// development keeps zap's ISO8601 time unless the time options are set
+	if !development || layout != "" || precision != TimePrecisionSeconds || location != nil {
+		encoderConfig.EncodeTime = formatEncodeTime(getTimeLayout(layout, precision), location)
+	}
+	// and zap's level encoding unless the level options are set
+	if !development || l.optionTable[OptionLevelEncoder].(LevelEncoder_e) != LevelEncoderCapital || l.optionTable[OptionColorLevel].(bool) {
+		encoderConfig.EncodeLevel = l.getLevelEncoder(format == EncoderFormatConsole)
+	}
+	applyEncoderKeys(&encoderConfig, l.optionTable[OptionEncoderKeys].(EncoderKeys_t))
+	encoderConfig.EncodeCaller = getCallerEncoder(l.optionTable[OptionCallerStyle].(CallerStyle_e))
+	// zap.AddCaller and zap.AddStacktrace may come with OptionZapOptions
+	zapOptions := len(l.optionTable[OptionZapOptions].([]zap.Option)) > 0
+	if !l.optionTable[OptionAddCaller].(bool) && !development && !zapOptions {
+		encoderConfig.CallerKey = zapcore.OmitKey
+	}
+	if l.optionTable[OptionDisableTimestamp].(bool) {
+		encoderConfig.TimeKey = zapcore.OmitKey
+	}
+	if _, ok := l.optionTable[OptionStacktraceLevel].(LogLevel_e); !ok && !development && !zapOptions {
+		encoderConfig.StacktraceKey = zapcore.OmitKey
+	}
+	lineEnding := l.optionTable[OptionLineEnding].(string)
+	encoderConfig.LineEnding = lineEnding
+	var encoder zapcore.Encoder
+	switch format {
+	case EncoderFormatJson:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+		if l.optionTable[OptionStableFieldOrder].(bool) {
+			encoder = newStableOrderEncoder(encoder, encoderConfig)
+		}
+	case EncoderFormatLogfmt:
+		encoder = newLogfmtEncoder(encoderConfig)
+	default:
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+	if lineEnding == "" {
+		// zap falls back to "\n" for an empty LineEnding
+		encoder = noLineEndingEncoder_t{encoder}
+	}
+	return l.prefixEncoder(encoder)
+}
+
+// with OptionLinePrefix the prefix goes in front of every entry
+func (l *Logger) prefixEncoder(encoder zapcore.Encoder) zapcore.Encoder {
+	prefix := l.optionTable[OptionLinePrefix].(string)
+	if prefix == "" {
+		return encoder
+	}
+	return linePrefixEncoder_t{Encoder: encoder, prefix: prefix}
+}
+
+// colors are only used by the console encoder
+func (l *Logger) getLevelEncoder(console bool) zapcore.LevelEncoder {
+	style := l.optionTable[OptionLevelEncoder].(LevelEncoder_e)
+	color := l.optionTable[OptionColorLevel].(bool)
+	switch style {
+	case LevelEncoderLowercase:
+		if color && console {
+			return zapcore.LowercaseColorLevelEncoder
+		}
+		return zapcore.LowercaseLevelEncoder
+	case LevelEncoderCapitalColor:
+		if console {
+			return zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.CapitalLevelEncoder
+	case LevelEncoderLowercaseColor:
+		if console {
+			return zapcore.LowercaseColorLevelEncoder
+		}
+		return zapcore.LowercaseLevelEncoder
+	default:
+		if color && console {
+			return zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.CapitalLevelEncoder
+	}
+}
+
+// zapcore caller encoder for the OptionCallerStyle value
+func getCallerEncoder(style CallerStyle_e) zapcore.CallerEncoder {
+	switch style {
+	case CallerStyleFull:
+		return zapcore.FullCallerEncoder
+	case CallerStyleBase:
+		return func(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+			if !caller.Defined {
+				enc.AppendString("undefined")
+				return
+			}
+			enc.AppendString(filepath.Base(caller.File) + ":" + strconv.Itoa(caller.Line))
+		}
+	default:
+		return zapcore.ShortCallerEncoder
+	}
+}
+
+// empty keys keep the zap defaults
+func applyEncoderKeys(encoderConfig *zapcore.EncoderConfig, keys EncoderKeys_t) {
+	if keys.LevelKey != "" {
+		encoderConfig.LevelKey = keys.LevelKey
+	}
+	if keys.TimeKey != "" {
+		encoderConfig.TimeKey = keys.TimeKey
+	}
+	if keys.MessageKey != "" {
+		encoderConfig.MessageKey = keys.MessageKey
+	}
+	if keys.CallerKey != "" {
+		encoderConfig.CallerKey = keys.CallerKey
+	}
+	if keys.NameKey != "" {
+		encoderConfig.NameKey = keys.NameKey
+	}
+}
+
+// the precision only extends the default layout, a custom layout is used as is
+func getTimeLayout(layout string, precision TimePrecision_e) string {
+	if layout != "" {
+		return layout
+	}
+	switch precision {
+	case TimePrecisionMillis:
+		return defaultTimeFormat + ".000"
+	case TimePrecisionMicros:
+		return defaultTimeFormat + ".000000"
+	case TimePrecisionNanos:
+		return defaultTimeFormat + ".000000000"
+	default:
+		return defaultTimeFormat
+	}
+}
+
+// a nil location keeps the location of the entry time
+func formatEncodeTime(layout string, location *time.Location) zapcore.TimeEncoder {
+	switch layout {
+	case TimeFormatEpochNanos:
+		return zapcore.EpochNanosTimeEncoder
+	case "":
+		layout = defaultTimeFormat
+	}
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		if location != nil {
+			t = t.In(location)
+		}
+		enc.AppendString(t.Format(layout))
+	}
+}
+
+func (l *Logger) logWriteInit() error {
+	if !l.optionTable[OptionLogDisableSave].(bool) {
+		fileWriter, err := newRotateFileWriter(l.path, l.optionTable)
+		if err != nil {
+			return err
+		}
+		l.fileWriter = fileWriter
+		l.writerList = append(l.writerList, writerInfo_t{
+			writer:     l.fileWriter,
+			kind:       writerKindFile,
+			dualEncode: l.optionTable[OptionDualEncode].(bool),
+			fallback:   l.fallback,
+		})
+	}
+	// with OptionSplitStderr the console cores are built in initLogger
+	if !l.optionTable[OptionDisableConsole].(bool) && !l.splitStderr() {
+		l.writerList = append(l.writerList, writerInfo_t{
+			writer: os.Stdout,
+			kind:   writerKindConsole,
+		})
+	}
+	return nil
+}
+
+// zap samples per level and message, fields are not part of the key
+func newSamplerCore(core zapcore.Core, sampling Sampling_t) zapcore.Core {
+	tick := sampling.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return zapcore.NewSamplerWithOptions(core, tick, sampling.Initial, sampling.Thereafter)
+}
+
+// the caller must hold l.mu
+func (l *Logger) bufferWriter(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+	size := l.optionTable[OptionBufferSize].(int)
+	if size <= 0 {
+		l.stopBufferedWriter()
+		return ws
+	}
+	interval := l.optionTable[OptionFlushInterval].(time.Duration)
+	if b := l.bufferedWriter; b != nil && b.Size == size && b.FlushInterval == interval {
+		// the entries buffered so far belong to the previous writers
+		b.Sync()
+		l.bufferTarget.set(ws)
+		return b
+	}
+	l.stopBufferedWriter()
+	l.bufferTarget = &swapWriteSyncer_t{ws: ws}
+	l.bufferedWriter = &zapcore.BufferedWriteSyncer{
+		WS:            l.bufferTarget,
+		Size:          size,
+		FlushInterval: interval,
+	}
+	return l.bufferedWriter
+}
+
+// flush and stop the timer of the current buffer, Sync and Close still
+// flush it. The caller must hold l.mu
+func (l *Logger) stopBufferedWriter() {
+	if l.bufferedWriter != nil {
+		l.bufferedWriter.Stop()
+		l.stoppedBuffers = append(l.stoppedBuffers, l.bufferedWriter)
+		l.bufferedWriter = nil
+		l.bufferTarget = nil
+	}
+}
+
+// the caller must hold l.mu
+func (l *Logger) syncStoppedBuffers() error {
+	var err error
+	for _, b := range l.stoppedBuffers {
+		err = multierr.Append(err, b.Sync())
+	}
+	return err
+}
+
+func (l *Logger) splitStderr() bool {
+	return l.optionTable[OptionSplitStderr].(bool) && !l.optionTable[OptionDisableConsole].(bool)
+}
+
+// debug and info to stdout, warn and above to stderr
+func splitConsoleCores(encoder zapcore.Encoder, level zapcore.LevelEnabler) []zapcore.Core {
+	stdoutLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return level.Enabled(lvl) && lvl < zapcore.WarnLevel
+	})
+	stderrLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return level.Enabled(lvl) && lvl >= zapcore.WarnLevel
+	})
+	return []zapcore.Core{
+		zapcore.NewCore(encoder, getWriter([]writerInfo_t{{writer: os.Stdout, kind: writerKindConsole}}), stdoutLevel),
+		zapcore.NewCore(encoder, getWriter([]writerInfo_t{{writer: os.Stderr, kind: writerKindConsole}}), stderrLevel),
+	}
+}
+
+// drop the file and stdout writers of a previous init, user writers are kept
+func (l *Logger) removeInternalWriters() {
+	wl := []writerInfo_t{}
+	for _, w := range l.writerList {
+		if w.kind == writerKindUser {
+			wl = append(wl, w)
+		}
+	}
+	l.writerList = wl
+	if l.fileWriter != nil {
+		l.fileWriter.Close()
+		l.fileWriter = nil
+	}
+}
+
+// with OptionSyncOnWrite every write is followed by a sync
+func (l *Logger) syncWriter(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+	if !l.optionTable[OptionSyncOnWrite].(bool) {
+		return ws
+	}
+	return syncWriteSyncer_t{ws}
+}
+
+func getWriter(writerList []writerInfo_t) zapcore.WriteSyncer {
+	if len(writerList) == 1 {
+		return singleWriteSyncer_t{writerList[0]}
+	}
+	return newMultiWriteSyncer(writerList)
+}
+
+func (l *Logger) optionHandler(options ...LogOption_t) error {
+	return setOptions(l.optionTable, options...)
+}
+
+// nothing is applied when one of the values has the wrong type
+func setOptions(optionTable map[OptionType_e]interface{}, options ...LogOption_t) error {
+	for _, o := range options {
+		if err := checkOption(o); err != nil {
+			return err
+		}
+	}
+	for k := range optionTable {
+		for _, o := range options {
+			if k == o.Option {
+				optionTable[k] = o.Value
+			}
+		}
+	}
+	return nil
+}
+
+func checkOption(o LogOption_t) error {
+	t, ok := optionTypes[o.Option]
+	if !ok {
+		return nil
+	}
+	if o.Value == nil {
+		// only options which are unset by default can be reset
+		if newOptionTable()[o.Option] == nil {
+			return nil
+		}
+		return fmt.Errorf("zapLog: option %d expects %v, got nil", o.Option, t)
+	}
+	vt := reflect.TypeOf(o.Value)
+	if t.Kind() == reflect.Interface {
+		ok = vt.Implements(t)
+	} else {
+		ok = vt == t
+	}
+	if !ok {
+		return fmt.Errorf("zapLog: option %d expects %v, got %v", o.Option, t, vt)
+	}
+	return nil
+}
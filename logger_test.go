@@ -0,0 +1,962 @@
+package zapLog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// bytes.Buffer safe for the concurrent writes of the loggers
+type syncBuffer_t struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer_t) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer_t) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer_t) lines() []string {
+	s := strings.TrimSuffix(b.String(), "\n")
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\n")
+}
+
+// logger writing only to the returned buffer, no file and no stdout
+func newTestLogger(t *testing.T, options ...LogOption_t) (*Logger, *syncBuffer_t) {
+	t.Helper()
+	options = append([]LogOption_t{
+		{Option: OptionDisableConsole, Value: true},
+		{Option: OptionLogDisableSave, Value: true},
+	}, options...)
+	l, err := NewLogger("", options...)
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	// stops the flush goroutine of OptionBufferSize, it reads os.Stdout
+	// which captureOutput replaces
+	t.Cleanup(func() { l.Close() })
+	buf := &syncBuffer_t{}
+	l.AddWriter(buf)
+	return l, buf
+}
+
+func decodeJSONLine(t *testing.T, line string) map[string]interface{} {
+	t.Helper()
+	m := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		t.Fatalf("not a json line %q: %v", line, err)
+	}
+	return m
+}
+
+func TestCallerKeyOmittedWithoutCaller(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	l.GetLogger().Error("no caller")
+
+	m := decodeJSONLine(t, buf.lines()[0])
+	if _, ok := m["caller"]; ok {
+		t.Errorf("caller written without OptionAddCaller: %v", m)
+	}
+	if _, ok := m["stacktrace"]; ok {
+		t.Errorf("stacktrace written without OptionStacktraceLevel: %v", m)
+	}
+}
+
+func TestCallerKeyKeptForZapOptions(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionZapOptions, Value: []zap.Option{zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)}},
+	)
+	l.GetLogger().Error("with caller")
+
+	m := decodeJSONLine(t, buf.lines()[0])
+	if caller, _ := m["caller"].(string); !strings.Contains(caller, "logger_test.go") {
+		t.Errorf("caller = %q, want logger_test.go", caller)
+	}
+	if _, ok := m["stacktrace"]; !ok {
+		t.Errorf("stacktrace missing: %v", m)
+	}
+}
+
+func TestWriterKinds(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(filepath.Join(dir, "main.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	uids := map[string]string{}
+	_, uids["AddWriter"] = l.AddWriter(&syncBuffer_t{})
+	_, uids["AddLabeledWriter"] = l.AddLabeledWriter("audit", &syncBuffer_t{})
+	_, uids["AddRingBuffer"], _ = l.AddRingBuffer(10)
+	if _, uids["AddFileWriter"], err = l.AddFileWriter(filepath.Join(dir, "extra.log")); err != nil {
+		t.Fatal(err)
+	}
+	if _, uids["AddNetworkWriter"], err = l.AddNetworkWriter("tcp", listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	if _, uids["AddHTTPWriter"], err = l.AddHTTPWriter(server.URL, 10, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[string]writerKind_e{}
+	internal := map[writerKind_e]int{}
+	for _, w := range l.writerList {
+		if w.uid == "" {
+			internal[w.kind]++
+			continue
+		}
+		kinds[w.uid] = w.kind
+	}
+	for path, uid := range uids {
+		if kind, ok := kinds[uid]; !ok || kind != writerKindUser {
+			t.Errorf("%s: kind %v (found %v), want writerKindUser", path, kind, ok)
+		}
+	}
+	if internal[writerKindFile] != 1 || internal[writerKindConsole] != 1 || len(internal) != 2 {
+		t.Errorf("internal writers = %v, want one file and one console writer", internal)
+	}
+	if got := len(l.Writers()); got != len(uids) {
+		t.Errorf("Writers() has %d uids, want %d", got, len(uids))
+	}
+
+	if _, removed := l.RemoveWriter(""); removed {
+		t.Error("RemoveWriter(\"\") removed an internal writer")
+	}
+	if got := len(l.writerList); got != len(uids)+2 {
+		t.Errorf("%d writers left, want %d", got, len(uids)+2)
+	}
+}
+
+func TestDevelopmentFormat(t *testing.T) {
+	prod, prodBuf := newTestLogger(t)
+	dev, devBuf := newTestLogger(t, LogOption_t{Option: OptionDevelopment, Value: true})
+	prod.GetLogger().Info("hello")
+	dev.GetLogger().Info("hello")
+
+	prodLine, devLine := prodBuf.lines()[0], devBuf.lines()[0]
+	if strings.Contains(prodLine, "logger_test.go") {
+		t.Errorf("production line has a caller: %q", prodLine)
+	}
+	if !strings.Contains(devLine, "logger_test.go") {
+		t.Errorf("development line has no caller: %q", devLine)
+	}
+	// ISO8601, e.g. 2024-01-02T15:04:05.000Z
+	if !strings.Contains(strings.Fields(devLine)[0], "T") {
+		t.Errorf("development time is not ISO8601: %q", devLine)
+	}
+	if !strings.Contains(devLine, "\tINFO\t") {
+		t.Errorf("development level encoding changed: %q", devLine)
+	}
+}
+
+// writer whose Close waits until release is closed
+type slowCloser_t struct {
+	syncBuffer_t
+	release chan struct{}
+}
+
+func (w *slowCloser_t) Close() error {
+	<-w.release
+	return nil
+}
+
+func TestDrainTimeout(t *testing.T) {
+	l, buf := newTestLogger(t)
+	slow := &slowCloser_t{release: make(chan struct{})}
+	defer close(slow.release)
+	l.AddWriter(slow)
+	logger := l.GetLogger()
+	logger.Info("before")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Drain took %v", elapsed)
+	}
+
+	// the slow Close must not hold the logger
+	done := make(chan struct{})
+	go func() {
+		l.AddWriter(&syncBuffer_t{})
+		l.GetLogger().Info("after drain")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("logger blocked while the writers close")
+	}
+	logger.Info("old logger after drain")
+
+	if lines := buf.lines(); len(lines) != 1 {
+		t.Errorf("got %q, want only the entry before Drain", lines)
+	}
+}
+
+func TestBufferFlushInterval(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionFlushInterval, Value: 20 * time.Millisecond},
+	)
+	l.GetLogger().Info("buffered")
+	if lines := buf.lines(); len(lines) != 0 {
+		t.Fatalf("entry written before the flush: %q", lines)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(buf.lines()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if lines := buf.lines(); len(lines) != 1 {
+		t.Errorf("got %q, want the entry flushed by the interval", lines)
+	}
+}
+
+func BenchmarkBufferSize(b *testing.B) {
+	for _, size := range []int{0, 256 * 1024} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			l, err := NewLogger(filepath.Join(b.TempDir(), "bench.log"),
+				LogOption_t{Option: OptionDisableConsole, Value: true},
+				LogOption_t{Option: OptionBufferSize, Value: size},
+			)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer l.Close()
+			logger := l.GetLogger()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.Infow("benchmark", "i", i)
+			}
+		})
+	}
+}
+
+func TestRemoveMiddleWriter(t *testing.T) {
+	l, _ := newTestLogger(t)
+	first, second, third := &syncBuffer_t{}, &syncBuffer_t{}, &syncBuffer_t{}
+	_, uid1 := l.AddWriter(first)
+	_, uid2 := l.AddWriter(second)
+	_, uid3 := l.AddWriter(third)
+
+	if _, removed := l.RemoveWriter(uid2); !removed {
+		t.Fatal("RemoveWriter returned false")
+	}
+	if _, removed := l.RemoveWriter(uid2); removed {
+		t.Error("second RemoveWriter of the same uid returned true")
+	}
+	l.GetLogger().Info("entry")
+
+	if first.String() == "" || third.String() == "" {
+		t.Error("remaining writers got no entry")
+	}
+	if second.String() != "" {
+		t.Errorf("removed writer got %q", second.String())
+	}
+	uids := l.Writers()
+	if len(uids) != 3 || uids[1] != uid1 || uids[2] != uid3 {
+		t.Errorf("Writers() = %v, want the test buffer, %s and %s", uids, uid1, uid3)
+	}
+}
+
+func TestJSONEncoderFormat(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	l.GetLogger().Infow("structured", "user", "alice", "count", 3)
+
+	m := decodeJSONLine(t, buf.lines()[0])
+	if m["msg"] != "structured" || m["level"] != "INFO" || m["user"] != "alice" || m["count"] != float64(3) {
+		t.Errorf("decoded %v", m)
+	}
+}
+
+// meant for go test -race
+func TestConcurrentWriters(t *testing.T) {
+	l, _ := newTestLogger(t)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				l.GetLogger().Info("concurrent")
+			}
+		}
+	}()
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				_, uid := l.AddWriter(&syncBuffer_t{})
+				l.ChangeLogLevel(LogLevelDebug)
+				l.RemoveWriter(uid)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	if uids := l.Writers(); len(uids) != 1 {
+		t.Errorf("%d writers left, want only the test buffer", len(uids))
+	}
+}
+
+func TestInitUnwritablePath(t *testing.T) {
+	dir := t.TempDir()
+	// a regular file where the directory should be, root can't write there
+	// either
+	notDir := filepath.Join(dir, "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLogger(filepath.Join(notDir, "app.log"), LogOption_t{Option: OptionDisableConsole, Value: true}); err == nil {
+		t.Error("NewLogger below a regular file returned no error")
+	}
+	if _, err := NewLogger(dir, LogOption_t{Option: OptionDisableConsole, Value: true}); err == nil {
+		t.Error("NewLogger with a directory as log file returned no error")
+	}
+}
+
+func TestStacktraceLevel(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionStacktraceLevel, Value: LogLevelError},
+	)
+	l.GetLogger().Warn("below")
+	l.GetLogger().Error("at level")
+
+	lines := buf.lines()
+	if _, ok := decodeJSONLine(t, lines[0])["stacktrace"]; ok {
+		t.Error("warn entry has a stacktrace")
+	}
+	if stack, _ := decodeJSONLine(t, lines[1])["stacktrace"].(string); !strings.Contains(stack, "TestStacktraceLevel") {
+		t.Errorf("error entry stacktrace = %q", stack)
+	}
+}
+
+// what fn writes to the file that *f points to, e.g. &os.Stdout
+func captureOutput(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := *f
+	*f = w
+	done := make(chan string)
+	go func() {
+		var out bytes.Buffer
+		out.ReadFrom(r)
+		done <- out.String()
+	}()
+	defer func() {
+		*f = saved
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestDisableConsole(t *testing.T) {
+	dir := t.TempDir()
+	out := captureOutput(t, &os.Stdout, func() {
+		l, err := NewLogger(filepath.Join(dir, "app.log"), LogOption_t{Option: OptionDisableConsole, Value: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.GetLogger().Info("file only")
+		l.Close()
+	})
+	if out != "" {
+		t.Errorf("stdout got %q", out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "app.log")); !strings.Contains(string(data), "file only") {
+		t.Errorf("log file has %q", data)
+	}
+}
+
+func TestWritersList(t *testing.T) {
+	l, err := NewLogger("", LogOption_t{Option: OptionLogDisableSave, Value: true}, LogOption_t{Option: OptionDisableConsole, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uids := l.Writers(); len(uids) != 0 {
+		t.Errorf("Writers() = %v before AddWriter", uids)
+	}
+	_, uid1 := l.AddWriter(&syncBuffer_t{})
+	_, uid2 := l.AddWriter(&syncBuffer_t{})
+	if uids := l.Writers(); len(uids) != 2 || uids[0] != uid1 || uids[1] != uid2 {
+		t.Errorf("Writers() = %v, want [%s %s]", uids, uid1, uid2)
+	}
+}
+
+func TestFieldsSurviveLevelChange(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	l.SetGlobalFields(map[string]interface{}{"service": "api"})
+	derived := l.WithFields(map[string]interface{}{"request": "r1"})
+	l.ChangeLogLevel(LogLevelDebug)
+	derived.Debug("derived")
+	l.GetLogger().Debug("global")
+
+	lines := buf.lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	first, second := decodeJSONLine(t, lines[0]), decodeJSONLine(t, lines[1])
+	if first["request"] != "r1" || first["service"] != "api" {
+		t.Errorf("derived entry = %v, want request and service", first)
+	}
+	if second["service"] != "api" {
+		t.Errorf("entry after ChangeLogLevel = %v, want service", second)
+	}
+	if _, ok := second["request"]; ok {
+		t.Errorf("WithFields field leaked into the base logger: %v", second)
+	}
+}
+
+func TestSplitStderr(t *testing.T) {
+	var stdout string
+	stderr := captureOutput(t, &os.Stderr, func() {
+		stdout = captureOutput(t, &os.Stdout, func() {
+			l, err := NewLogger("",
+				LogOption_t{Option: OptionLogDisableSave, Value: true},
+				LogOption_t{Option: OptionSplitStderr, Value: true},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.GetLogger().Info("to stdout")
+			l.GetLogger().Warn("to stderr")
+		})
+	})
+	if !strings.Contains(stdout, "to stdout") || strings.Contains(stdout, "to stderr") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if !strings.Contains(stderr, "to stderr") || strings.Contains(stderr, "to stdout") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionSampling, Value: Sampling_t{Initial: 3, Thereafter: 10, Tick: time.Minute}})
+	for i := 0; i < 100; i++ {
+		l.GetLogger().Info("same message")
+	}
+	l.GetLogger().Info("other message")
+
+	// 3 and then the 13th, 23rd, ... 93rd
+	if got := strings.Count(buf.String(), "same message"); got != 12 {
+		t.Errorf("%d of 100 identical entries written, want 12", got)
+	}
+	if !strings.Contains(buf.String(), "other message") {
+		t.Error("a different message was sampled away")
+	}
+}
+
+func TestGetWriter(t *testing.T) {
+	l, _ := newTestLogger(t)
+	var buf bytes.Buffer
+	_, uid := l.AddWriter(&buf)
+	l.GetLogger().Info("round trip")
+
+	w, ok := l.GetWriter(uid)
+	if !ok {
+		t.Fatal("GetWriter didn't find the writer")
+	}
+	if got := w.(*bytes.Buffer).String(); !strings.Contains(got, "round trip") {
+		t.Errorf("writer has %q", got)
+	}
+	if _, ok := l.GetWriter(""); ok {
+		t.Error("GetWriter returned an internal writer")
+	}
+}
+
+func TestInitTwice(t *testing.T) {
+	dir := t.TempDir()
+	captureOutput(t, &os.Stdout, func() {
+		l := newLogger()
+		for _, name := range []string{"first.log", "second.log"} {
+			if err := l.init(filepath.Join(dir, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		defer l.Close()
+
+		kinds := map[writerKind_e]int{}
+		for _, w := range l.writerList {
+			kinds[w.kind]++
+		}
+		if kinds[writerKindFile] != 1 || kinds[writerKindConsole] != 1 || len(l.writerList) != 2 {
+			t.Errorf("writers after two inits = %v, want one file and one console writer", kinds)
+		}
+		if l.LogPath() != filepath.Join(dir, "second.log") {
+			t.Errorf("LogPath() = %q, want the second file", l.LogPath())
+		}
+	})
+}
+
+type traceKey_t struct{}
+
+func TestFromContext(t *testing.T) {
+	extractor := func(ctx context.Context) (string, string) {
+		ids, _ := ctx.Value(traceKey_t{}).([2]string)
+		return ids[0], ids[1]
+	}
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionTraceExtractor, Value: extractor},
+	)
+	ctx := context.WithValue(context.Background(), traceKey_t{}, [2]string{"trace-1", "span-1"})
+	l.FromContext(ctx).Info("traced")
+	l.FromContext(context.Background()).Info("untraced")
+
+	lines := buf.lines()
+	traced, untraced := decodeJSONLine(t, lines[0]), decodeJSONLine(t, lines[1])
+	if traced["trace_id"] != "trace-1" || traced["span_id"] != "span-1" {
+		t.Errorf("traced entry = %v", traced)
+	}
+	if _, ok := untraced["trace_id"]; ok {
+		t.Errorf("untraced entry = %v", untraced)
+	}
+}
+
+func TestBeforeInit(t *testing.T) {
+	l := newLogger()
+	if l.GetLogger() != nil {
+		t.Error("GetLogger before Init is not nil")
+	}
+	l.ChangeLogLevel(LogLevelDebug)
+	if err := l.Sync(); err != nil {
+		t.Errorf("Sync before Init = %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close before Init = %v", err)
+	}
+}
+
+func TestWrongOptionTypes(t *testing.T) {
+	wrong := []LogOption_t{
+		{Option: OptionLogLevel, Value: "debug"},
+		{Option: OptionLogMaxSize, Value: int64(10)},
+		{Option: OptionLogCompress, Value: 1},
+		{Option: OptionEncoderFormat, Value: "json"},
+		{Option: OptionTimeZone, Value: "UTC"},
+		{Option: OptionInternalErrorWriter, Value: "stderr"},
+		{Option: OptionDisableConsole, Value: nil},
+	}
+	for _, o := range wrong {
+		if _, err := NewLogger("", o); err == nil {
+			t.Errorf("option %d with %T was accepted", o.Option, o.Value)
+		}
+	}
+	if _, err := NewLogger("", LogOption_t{Option: OptionLogDisableSave, Value: true}, LogOption_t{Option: OptionInternalErrorWriter, Value: &syncBuffer_t{}}); err != nil {
+		t.Errorf("io.Writer implementation rejected: %v", err)
+	}
+}
+
+func TestNewNop(t *testing.T) {
+	// where lumberjack writes when it gets no file name
+	defaultFile := filepath.Join(os.TempDir(), filepath.Base(os.Args[0])+"-lumberjack.log")
+	os.Remove(defaultFile)
+	out := captureOutput(t, &os.Stdout, func() {
+		l := NewNop()
+		l.GetLogger().Error("dropped")
+		l.Sync()
+		if l.LogPath() != "" {
+			t.Errorf("LogPath() = %q", l.LogPath())
+		}
+	})
+	if out != "" {
+		t.Errorf("stdout got %q", out)
+	}
+	if _, err := os.Stat(defaultFile); err == nil {
+		t.Errorf("%s was created", defaultFile)
+	}
+}
+
+func TestRemoveAllWritersKeepsInternal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path, LogOption_t{Option: OptionDisableConsole, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	added := &syncBuffer_t{}
+	l.AddWriter(added)
+	l.AddWriter(&syncBuffer_t{})
+
+	l.RemoveAllWriters()
+	l.GetLogger().Info("after remove all")
+	if uids := l.Writers(); len(uids) != 0 {
+		t.Errorf("Writers() = %v", uids)
+	}
+	if added.String() != "" {
+		t.Errorf("removed writer got %q", added.String())
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "after remove all") {
+		t.Errorf("log file has %q", data)
+	}
+}
+
+func TestInitialFields(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionInitialFields, Value: map[string]interface{}{"env": "prod"}},
+	)
+	l.GetLogger().Info("before")
+	l.ChangeLogLevel(LogLevelDebug)
+	l.GetLogger().Debug("after")
+
+	for _, line := range buf.lines() {
+		if m := decodeJSONLine(t, line); m["env"] != "prod" {
+			t.Errorf("entry without the initial field: %v", m)
+		}
+	}
+}
+
+// records the Flush and Close calls made by the logger
+type closeRecorder_t struct {
+	syncBuffer_t
+	flushed, closed bool
+}
+
+func (w *closeRecorder_t) Flush() error {
+	w.flushed = true
+	return nil
+}
+
+func (w *closeRecorder_t) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestCloseWriters(t *testing.T) {
+	l, _ := newTestLogger(t)
+	w := &closeRecorder_t{}
+	l.AddWriter(w)
+	l.GetLogger().Info("before close")
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !w.flushed || !w.closed {
+		t.Errorf("flushed = %v, closed = %v, want both", w.flushed, w.closed)
+	}
+	if !strings.Contains(w.String(), "before close") {
+		t.Errorf("entry not written before close: %q", w.String())
+	}
+}
+
+func TestFileOnlyConsoleOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	out := captureOutput(t, &os.Stdout, func() {
+		l, err := NewLogger(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.FileOnly().Info("verbose diagnostics")
+		l.ConsoleOnly().Info("progress")
+		l.Close()
+	})
+	if strings.Contains(out, "verbose diagnostics") || !strings.Contains(out, "progress") {
+		t.Errorf("stdout got %q", out)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "verbose diagnostics") || strings.Contains(string(data), "progress") {
+		t.Errorf("log file has %q", data)
+	}
+}
+
+func TestLogPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path, LogOption_t{Option: OptionDisableConsole, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got := l.LogPath(); got != path {
+		t.Errorf("LogPath() = %q, want %q", got, path)
+	}
+
+	l, _ = newTestLogger(t)
+	if got := l.LogPath(); got != "" {
+		t.Errorf("LogPath() without saving = %q", got)
+	}
+}
+
+func TestNamed(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	l.Named("http").Info("server")
+	l.Named("http").Named("client").Info("request")
+
+	lines := buf.lines()
+	for i, want := range []string{"http", "http.client"} {
+		if name := decodeJSONLine(t, lines[i])["logger"]; name != want {
+			t.Errorf("line %d logger = %v, want %s", i, name, want)
+		}
+	}
+	if newLogger().Named("http") != nil {
+		t.Error("Named before Init is not nil")
+	}
+}
+
+func TestSetWriters(t *testing.T) {
+	l, old := newTestLogger(t)
+	internal := &syncBuffer_t{}
+	l.addWriter(writerInfo_t{writer: internal, kind: writerKindFile})
+
+	// the writer set is swapped in one rebuild, every entry reaches either
+	// the old or the new writers
+	first, second := &syncBuffer_t{}, &syncBuffer_t{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			l.GetLogger().Infow("entry", "n", i)
+		}
+	}()
+	uids := l.SetWriters(first, second)
+	<-done
+
+	if got := l.Writers(); !reflect.DeepEqual(got, uids) {
+		t.Errorf("Writers() = %v, want %v", got, uids)
+	}
+	if n := len(old.lines()) + len(first.lines()); n != 200 {
+		t.Errorf("old and new writers got %d entries, want 200", n)
+	}
+	if len(first.lines()) != len(second.lines()) {
+		t.Errorf("new writers got %d and %d entries", len(first.lines()), len(second.lines()))
+	}
+	if len(internal.lines()) != 200 {
+		t.Errorf("internal writer got %d entries, want 200", len(internal.lines()))
+	}
+}
+
+func TestIncludeHostAndPID(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionIncludeHost, Value: true},
+		LogOption_t{Option: OptionIncludePID, Value: true},
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	l.GetLogger().Info("tagged")
+
+	m := decodeJSONLine(t, buf.lines()[0])
+	if m["host"] != host || m["pid"] != float64(os.Getpid()) {
+		t.Errorf("host = %v, pid = %v, want %s and %d", m["host"], m["pid"], host, os.Getpid())
+	}
+
+	l, buf = newTestLogger(t, LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	l.GetLogger().Info("untagged")
+	if m := decodeJSONLine(t, buf.lines()[0]); m["host"] != nil || m["pid"] != nil {
+		t.Errorf("host or pid written by default: %v", m)
+	}
+}
+
+func TestClone(t *testing.T) {
+	l, buf := newTestLogger(t)
+	clone := l.Clone()
+	clone.ChangeLogLevel(LogLevelDebug)
+	if l.Enabled(LogLevelDebug) {
+		t.Error("the clone level changed the original")
+	}
+	l.GetLogger().Debug("original debug")
+	clone.GetLogger().Debug("clone debug")
+
+	// the writers are shared
+	lines := buf.lines()
+	if len(lines) != 1 || !strings.Contains(lines[0], "clone debug") {
+		t.Errorf("got %q, want only the clone entry", lines)
+	}
+	clone.RemoveAllWriters()
+	l.GetLogger().Info("original info")
+	if lines := buf.lines(); len(lines) != 2 {
+		t.Errorf("removing the clone writers affected the original: %q", lines)
+	}
+}
+
+func TestWithScope(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	l.WithScope(map[string]interface{}{"request_id": "r-1"}, func(logger *zap.SugaredLogger) {
+		logger.Info("inside")
+	})
+	l.GetLogger().Info("outside")
+
+	lines := buf.lines()
+	if m := decodeJSONLine(t, lines[0]); m["request_id"] != "r-1" {
+		t.Errorf("inside line = %q", lines[0])
+	}
+	if m := decodeJSONLine(t, lines[1]); m["request_id"] != nil {
+		t.Errorf("outside line = %q", lines[1])
+	}
+
+	called := false
+	newLogger().WithScope(map[string]interface{}{"request_id": "r-2"}, func(logger *zap.SugaredLogger) {
+		called = true
+		logger.Info("dropped")
+	})
+	if !called {
+		t.Error("fn not called before Init")
+	}
+}
+
+func TestBufferKeptAcrossRebuilds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionFlushInterval, Value: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	early := l.GetLogger()
+	early.Info("before the rebuild")
+	l.AddWriter(&syncBuffer_t{})
+	early.Info("after the rebuild")
+	l.Close()
+
+	data, _ := os.ReadFile(path)
+	for _, msg := range []string{"before the rebuild", "after the rebuild"} {
+		if !strings.Contains(string(data), msg) {
+			t.Errorf("%q lost, log file has %q", msg, data)
+		}
+	}
+}
+
+func TestBufferReplacedByInit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionFlushInterval, Value: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	early := l.GetLogger()
+	if err := l.init(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionBufferSize, Value: 8192}); err != nil {
+		t.Fatal(err)
+	}
+	early.Info("to the old buffer")
+	l.Sync()
+
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "to the old buffer") {
+		t.Errorf("log file has %q after Sync", data)
+	}
+	l.Close()
+}
+
+func TestWriterLevel(t *testing.T) {
+	l, global := newTestLogger(t, LogOption_t{Option: OptionLogLevel, Value: LogLevelInfo})
+	debug, errorOnly := &syncBuffer_t{}, &syncBuffer_t{}
+	l.AddWriter(debug, LogLevelDebug)
+	l.AddWriter(errorOnly, LogLevelError)
+	l.GetLogger().Debug("debug")
+	l.GetLogger().Info("info")
+	l.GetLogger().Error("error")
+
+	if lines := global.lines(); len(lines) != 2 {
+		t.Errorf("writer at the logger level got %d lines, want info and error", len(lines))
+	}
+	if lines := debug.lines(); len(lines) != 3 || !strings.Contains(lines[0], "debug") {
+		t.Errorf("debug writer got %q, want all three entries", lines)
+	}
+	if lines := errorOnly.lines(); len(lines) != 1 || !strings.Contains(lines[0], "error") {
+		t.Errorf("error writer got %q, want only the error", lines)
+	}
+}
+
+func TestHooksOnceAfterRebuild(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	hook := func(e zapcore.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[e.Message]++
+		return nil
+	}
+	l, _ := newTestLogger(t, LogOption_t{Option: OptionHooks, Value: []func(zapcore.Entry) error{hook}})
+	l.GetLogger().Info("initial")
+	l.AddWriter(&syncBuffer_t{})
+	l.GetLogger().Info("after AddWriter")
+	l.ChangeLogLevel(LogLevelDebug)
+	l.GetLogger().Info("after ChangeLogLevel")
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, msg := range []string{"initial", "after AddWriter", "after ChangeLogLevel"} {
+		if calls[msg] != 1 {
+			t.Errorf("hooks ran %d times for %q, want 1", calls[msg], msg)
+		}
+	}
+}
+
+func TestHostLookedUpOnce(t *testing.T) {
+	lookups := 0
+	defer func(hostname func() (string, error)) { osHostname = hostname }(osHostname)
+	osHostname = func() (string, error) {
+		lookups++
+		return "build-host", nil
+	}
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionIncludeHost, Value: true},
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson})
+	l.AddWriter(&syncBuffer_t{})
+	l.ChangeLogLevel(LogLevelDebug)
+	l.Clone().GetLogger().Info("clone")
+	l.GetLogger().Info("rebuilt")
+
+	if lookups != 1 {
+		t.Errorf("os.Hostname called %d times, want once", lookups)
+	}
+	if m := decodeJSONLine(t, buf.lines()[0]); m["host"] != "build-host" {
+		t.Errorf("host = %v, want build-host", m["host"])
+	}
+}
@@ -0,0 +1,188 @@
+package zapLog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	netDialTimeout = 5 * time.Second
+	// first and longest wait between two dials while the collector is down
+	netRedialMin = 100 * time.Millisecond
+	netRedialMax = 30 * time.Second
+)
+
+// replaced in tests
+var netDial = func(network, address string) (net.Conn, error) {
+	return net.DialTimeout(network, address, netDialTimeout)
+}
+
+// connection to a remote collector. A failed write drops the connection
+// and it is dialed again in the background with a growing delay, entries
+// logged meanwhile are dropped and counted so logging never waits for the
+// collector.
+type netWriter_t struct {
+	mu      sync.Mutex
+	network string
+	address string
+	conn    net.Conn
+	// every connection carries its own gzip stream, the compressor holds
+	// entries until Sync or Close flushes it
+	compress bool
+	gz       *gzip.Writer
+	// a redial goroutine is running
+	redialing bool
+	// entries lost since the connection dropped
+	dropped int
+	closed  bool
+	// closed by Close, ends the redial goroutine
+	stop chan struct{}
+}
+
+func newNetWriter(network, address string, compress bool) (*netWriter_t, error) {
+	w := &netWriter_t{
+		network:  network,
+		address:  address,
+		compress: compress,
+		stop:     make(chan struct{}),
+	}
+	conn, err := netDial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	w.attach(conn)
+	return w, nil
+}
+
+// entries written after Close are dropped
+func (w *netWriter_t) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return len(p), nil
+	}
+	if w.conn == nil {
+		w.dropped++
+		w.redial()
+		return len(p), nil
+	}
+	if err := w.send(p); err != nil {
+		w.drop()
+		return 0, fmt.Errorf("zapLog: send to %s: %w", w.address, err)
+	}
+	if w.dropped > 0 {
+		// reported once the collector is back
+		dropped := w.dropped
+		w.dropped = 0
+		return len(p), fmt.Errorf("zapLog: %d entries dropped while %s was unreachable", dropped, w.address)
+	}
+	return len(p), nil
+}
+
+// push the compressed entries to the connection
+func (w *netWriter_t) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil || w.gz == nil {
+		return nil
+	}
+	if err := w.gz.Flush(); err != nil {
+		w.drop()
+		return err
+	}
+	return nil
+}
+
+func (w *netWriter_t) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	close(w.stop)
+	if w.conn == nil {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		// writes the rest of the stream and the gzip footer
+		err = w.gz.Close()
+	}
+	if closeErr := w.conn.Close(); err == nil {
+		err = closeErr
+	}
+	w.conn = nil
+	return err
+}
+
+// the caller must hold w.mu
+func (w *netWriter_t) send(p []byte) error {
+	if w.gz != nil {
+		_, err := w.gz.Write(p)
+		return err
+	}
+	_, err := w.conn.Write(p)
+	return err
+}
+
+// the caller must hold w.mu
+func (w *netWriter_t) drop() {
+	w.conn.Close()
+	w.conn = nil
+	w.redial()
+}
+
+// the caller must hold w.mu
+func (w *netWriter_t) redial() {
+	if w.redialing {
+		return
+	}
+	w.redialing = true
+	go w.redialLoop()
+}
+
+func (w *netWriter_t) redialLoop() {
+	delay := netRedialMin
+	for {
+		select {
+		case <-w.stop:
+			w.mu.Lock()
+			w.redialing = false
+			w.mu.Unlock()
+			return
+		case <-time.After(delay):
+		}
+		conn, err := netDial(w.network, w.address)
+		w.mu.Lock()
+		if err == nil && !w.closed {
+			w.attach(conn)
+			w.redialing = false
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+		if err == nil {
+			conn.Close()
+		}
+		if delay *= 2; delay > netRedialMax {
+			delay = netRedialMax
+		}
+	}
+}
+
+// the caller must hold w.mu unless w is not shared yet
+func (w *netWriter_t) attach(conn net.Conn) {
+	w.conn = conn
+	if w.compress {
+		// a new connection starts a new stream
+		if w.gz == nil {
+			w.gz = gzip.NewWriter(conn)
+		} else {
+			w.gz.Reset(conn)
+		}
+	}
+}
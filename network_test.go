@@ -0,0 +1,128 @@
+package zapLog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// everything sent to the first connection of the returned listener
+func acceptAll(t *testing.T) (net.Listener, chan []byte) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+	return listener, received
+}
+
+func TestNetworkWriter(t *testing.T) {
+	listener, received := acceptAll(t)
+	defer listener.Close()
+	l, _ := newTestLogger(t)
+	if _, _, err := l.AddNetworkWriter("tcp", listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	l.GetLogger().Info("over tcp")
+	l.Close()
+
+	if data := <-received; !strings.Contains(string(data), "over tcp") {
+		t.Errorf("listener got %q", data)
+	}
+}
+
+func TestNetworkWriterCompress(t *testing.T) {
+	listener, received := acceptAll(t)
+	defer listener.Close()
+	l, _ := newTestLogger(t)
+	_, _, err := l.AddNetworkWriter("tcp", listener.Addr().String(), LogOption_t{Option: OptionLogCompress, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.GetLogger().Info("first compressed")
+	l.GetLogger().Info("second compressed")
+	l.Close()
+
+	gz, err := gzip.NewReader(bytes.NewReader(<-received))
+	if err != nil {
+		t.Fatalf("not a gzip stream: %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("reading the stream: %v", err)
+	}
+	if !strings.Contains(string(data), "first compressed") || !strings.Contains(string(data), "second compressed") {
+		t.Errorf("decompressed %q", data)
+	}
+}
+
+func TestNetworkWriterRedial(t *testing.T) {
+	dials := make(chan net.Conn)
+	defer func(dial func(string, string) (net.Conn, error)) { netDial = dial }(netDial)
+	netDial = func(network, address string) (net.Conn, error) {
+		conn, ok := <-dials
+		if !ok {
+			return nil, errors.New("collector down")
+		}
+		return conn, nil
+	}
+	// the collector is down, the dial waits until the test answers it
+	w := &netWriter_t{network: "tcp", address: "collector:514", stop: make(chan struct{})}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := w.Write([]byte("while down\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("writes waited %v for the collector", elapsed)
+	}
+
+	client, server := net.Pipe()
+	defer server.Close()
+	dials <- client
+	deadline := time.Now().Add(time.Second)
+	for {
+		w.mu.Lock()
+		connected := w.conn != nil
+		w.mu.Unlock()
+		if connected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("not connected again")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	go io.Copy(io.Discard, server)
+	if _, err := w.Write([]byte("back\n")); err == nil || !strings.Contains(err.Error(), "3 entries dropped") {
+		t.Errorf("Write after the redial = %v, want the dropped count", err)
+	}
+
+	w.Close()
+	close(dials)
+	if _, err := w.Write([]byte("after close\n")); err != nil {
+		t.Errorf("Write after Close = %v", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil || w.redialing {
+		t.Error("Write after Close dialed again")
+	}
+}
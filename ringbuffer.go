@@ -0,0 +1,57 @@
+package zapLog
+
+import (
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// keeps the last entries written to it, e.g. for a live tail page.
+// Every Write is one entry, the logger never buffers the writes of a ring
+// buffer with OptionBufferSize.
+type RingBufferWriter struct {
+	mu      sync.RWMutex
+	entries []string
+	next    int
+	full    bool
+}
+
+func NewRingBufferWriter(capacity int) *RingBufferWriter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RingBufferWriter{
+		entries: make([]string, capacity),
+	}
+}
+
+func (r *RingBufferWriter) writesEntries() {}
+
+func (r *RingBufferWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = strings.TrimRight(string(p), "\r\n")
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// buffered entries, oldest first, without line endings
+func (r *RingBufferWriter) Lines() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.full {
+		return append([]string{}, r.entries[:r.next]...)
+	}
+	lines := append([]string{}, r.entries[r.next:]...)
+	return append(lines, r.entries[:r.next]...)
+}
+
+func (l *Logger) AddRingBuffer(capacity int) (*zap.SugaredLogger, string, *RingBufferWriter) {
+	r := NewRingBufferWriter(capacity)
+	sugarLogger, uid := l.AddWriter(r)
+	return sugarLogger, uid, r
+}
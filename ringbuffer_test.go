@@ -0,0 +1,38 @@
+package zapLog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRingBufferOverflow(t *testing.T) {
+	l, _ := newTestLogger(t)
+	_, _, ring := l.AddRingBuffer(3)
+	for i := 0; i < 5; i++ {
+		l.GetLogger().Infof("entry %d", i)
+	}
+
+	lines := ring.Lines()
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), lines)
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("entry %d", i+2); !strings.HasSuffix(line, want) {
+			t.Errorf("line %d = %q, want %q", i, line, want)
+		}
+	}
+}
+
+func TestRingBufferWithBufferSize(t *testing.T) {
+	l, _ := newTestLogger(t, LogOption_t{Option: OptionBufferSize, Value: 4096})
+	_, _, ring := l.AddRingBuffer(10)
+	for _, msg := range []string{"a", "b", "c"} {
+		l.GetLogger().Info(msg)
+	}
+	l.Sync()
+
+	if lines := ring.Lines(); len(lines) != 3 {
+		t.Errorf("got %d entries, want 3: %q", len(lines), lines)
+	}
+}
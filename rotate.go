@@ -0,0 +1,359 @@
+package zapLog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/natefinch/lumberjack"
+)
+
+const (
+	// same as lumberjack
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+	defaultMaxSize   = 100
+	megabyte         = 1024 * 1024
+)
+
+// lumberjack rotates silently, fileWriter_t follows the size of the current
+// file the same way lumberjack does to find out when a write rotated it
+type fileWriter_t struct {
+	mu       sync.Mutex
+	logger   *lumberjack.Logger
+	opened   bool
+	size     int64
+	onRotate func(oldPath string)
+	// OptionOnRotate
+	onRotateInfo func(RotateInfo)
+	// OptionFileMode, 0 when unset
+	mode os.FileMode
+}
+
+// lumberjack file at path configured by the rotation options of optionTable
+func newRotateFileWriter(path string, optionTable map[OptionType_e]interface{}) (*fileWriter_t, error) {
+	if mode, ok := optionTable[OptionFileMode].(os.FileMode); ok && path != "" {
+		// lumberjack keeps the mode of an existing file, minus the umask
+		// for the new file after a rotation
+		if err := createWithMode(path, mode); err != nil {
+			return nil, fmt.Errorf("zapLog: file mode of %s: %w", path, err)
+		}
+	}
+	onRotate, _ := optionTable[OptionRotateHook].(func(string))
+	w := newFileWriter(newLumberjack(path, getRotation(optionTable)), onRotate)
+	w.onRotateInfo, _ = optionTable[OptionOnRotate].(func(RotateInfo))
+	w.mode, _ = optionTable[OptionFileMode].(os.FileMode)
+	return w, nil
+}
+
+// OptionRotation when set, the single rotation options otherwise
+func getRotation(optionTable map[OptionType_e]interface{}) Rotation_t {
+	if rotation, ok := optionTable[OptionRotation].(Rotation_t); ok {
+		return rotation
+	}
+	return Rotation_t{
+		MaxSize:    optionTable[OptionLogMaxSize].(int),
+		MaxBackups: optionTable[OptionLogMaxBackup].(int),
+		MaxAge:     optionTable[OptionLogMaxAge].(int),
+		Compress:   optionTable[OptionLogCompress].(bool),
+		LocalTime:  optionTable[OptionLogLocalTime].(bool),
+	}
+}
+
+// OptionFileMode has to be a permission the owner can write with
+func checkFileMode(optionTable map[OptionType_e]interface{}) error {
+	mode, ok := optionTable[OptionFileMode].(os.FileMode)
+	if !ok {
+		return nil
+	}
+	if mode&^os.ModePerm != 0 || mode&0200 == 0 {
+		return fmt.Errorf("zapLog: file mode %v is not a writable permission", mode)
+	}
+	return nil
+}
+
+// the umask doesn't apply to the chmod
+func createWithMode(path string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, mode)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Chmod(path, mode)
+}
+
+func newLumberjack(path string, rotation Rotation_t) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    rotation.MaxSize,
+		MaxBackups: rotation.MaxBackups,
+		MaxAge:     rotation.MaxAge,
+		Compress:   rotation.Compress,
+		LocalTime:  rotation.LocalTime,
+	}
+}
+
+// change the rotation options of the log file without a new Init, e.g. to
+// keep fewer backups when the disk fills up. Only OptionLogMaxSize,
+// OptionLogMaxBackup, OptionLogMaxAge, OptionLogCompress, OptionLogLocalTime
+// and OptionRotation are accepted.
+func (l *Logger) UpdateRotation(options ...LogOption_t) error {
+	for _, o := range options {
+		if err := checkOption(o); err != nil {
+			return err
+		}
+		switch o.Option {
+		case OptionLogMaxSize, OptionLogMaxBackup, OptionLogMaxAge, OptionLogCompress, OptionLogLocalTime:
+		case OptionRotation:
+			if o.Value == nil {
+				return fmt.Errorf("zapLog: option %d expects %v, got nil", o.Option, optionTypes[o.Option])
+			}
+		default:
+			return fmt.Errorf("zapLog: option %d is not a rotation option", o.Option)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rotation := getRotation(l.optionTable)
+	for _, o := range options {
+		switch o.Option {
+		case OptionLogMaxSize:
+			rotation.MaxSize = o.Value.(int)
+		case OptionLogMaxBackup:
+			rotation.MaxBackups = o.Value.(int)
+		case OptionLogMaxAge:
+			rotation.MaxAge = o.Value.(int)
+		case OptionLogCompress:
+			rotation.Compress = o.Value.(bool)
+		case OptionLogLocalTime:
+			rotation.LocalTime = o.Value.(bool)
+		case OptionRotation:
+			rotation = o.Value.(Rotation_t)
+		}
+	}
+	if rotation.MaxSize < 0 || rotation.MaxBackups < 0 || rotation.MaxAge < 0 {
+		return fmt.Errorf("zapLog: rotation sizes and counts must not be negative: %+v", rotation)
+	}
+
+	setOptions(l.optionTable, options...)
+	if _, ok := l.optionTable[OptionRotation].(Rotation_t); ok {
+		l.optionTable[OptionRotation] = rotation
+	}
+	if l.fileWriter != nil {
+		l.fileWriter.setRotation(rotation)
+	}
+	return nil
+}
+
+func newFileWriter(logger *lumberjack.Logger, onRotate func(oldPath string)) *fileWriter_t {
+	return &fileWriter_t{
+		logger:   logger,
+		onRotate: onRotate,
+	}
+}
+
+func (w *fileWriter_t) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	writeLen := int64(len(p))
+	rotated := false
+	if !w.opened {
+		// lumberjack opens the existing file on the first write
+		if info, err := os.Stat(w.filename()); err == nil {
+			w.size = info.Size()
+			rotated = w.size+writeLen >= w.maxSize()
+		}
+	} else {
+		rotated = w.size+writeLen > w.maxSize()
+	}
+
+	oldSize := w.size
+	n, err := w.logger.Write(p)
+	if err != nil && n == 0 {
+		w.mu.Unlock()
+		return n, err
+	}
+	w.opened = true
+	var info *RotateInfo
+	if rotated {
+		w.size = 0
+		info = w.rotated(oldSize)
+	}
+	w.size += int64(n)
+	w.mu.Unlock()
+
+	if info != nil {
+		// the write may run under the locks of the logger, e.g. of the
+		// OptionBufferSize buffer, so a hook that logs can't run here
+		go w.notify(*info)
+	}
+	return n, err
+}
+
+// the hooks have run when Rotate returns
+func (w *fileWriter_t) Rotate() error {
+	w.mu.Lock()
+	oldSize := w.size
+	if !w.opened {
+		if info, err := os.Stat(w.filename()); err == nil {
+			oldSize = info.Size()
+		}
+	}
+	if err := w.logger.Rotate(); err != nil {
+		w.mu.Unlock()
+		return err
+	}
+	w.opened = true
+	w.size = 0
+	info := w.rotated(oldSize)
+	w.mu.Unlock()
+
+	if info != nil {
+		w.notify(*info)
+	}
+	return nil
+}
+
+// the fields are changed in place, a new lumberjack.Logger would leak the
+// goroutine the old one removes backups with. That goroutine reads
+// MaxBackups, MaxAge and Compress without a lock, so only the changed
+// fields are written.
+func (w *fileWriter_t) setRotation(rotation Rotation_t) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logger.MaxSize = rotation.MaxSize
+	w.logger.LocalTime = rotation.LocalTime
+	if w.logger.MaxBackups != rotation.MaxBackups {
+		w.logger.MaxBackups = rotation.MaxBackups
+	}
+	if w.logger.MaxAge != rotation.MaxAge {
+		w.logger.MaxAge = rotation.MaxAge
+	}
+	if w.logger.Compress != rotation.Compress {
+		w.logger.Compress = rotation.Compress
+	}
+}
+
+func (w *fileWriter_t) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.opened = false
+	return w.logger.Close()
+}
+
+// the caller must hold w.mu, the returned info is for notify which must be
+// called without it. nil when there are no hooks.
+func (w *fileWriter_t) rotated(oldSize int64) *RotateInfo {
+	if w.mode != 0 {
+		os.Chmod(w.filename(), w.mode)
+	}
+	if w.onRotate == nil && w.onRotateInfo == nil {
+		return nil
+	}
+	backups := backupFiles(w.filename())
+	if len(backups) == 0 {
+		return nil
+	}
+	return &RotateInfo{
+		Filename: w.filename(),
+		Backup:   backups[len(backups)-1],
+		Size:     oldSize,
+	}
+}
+
+// the hooks may log through the logger writing to w
+func (w *fileWriter_t) notify(info RotateInfo) {
+	if w.onRotate != nil {
+		w.onRotate(info.Backup)
+	}
+	if w.onRotateInfo != nil {
+		w.onRotateInfo(info)
+	}
+}
+
+func (w *fileWriter_t) filename() string {
+	if w.logger.Filename != "" {
+		return w.logger.Filename
+	}
+	return filepath.Join(os.TempDir(), filepath.Base(os.Args[0])+"-lumberjack.log")
+}
+
+func (w *fileWriter_t) maxSize() int64 {
+	if w.logger.MaxSize == 0 {
+		return int64(defaultMaxSize * megabyte)
+	}
+	return int64(w.logger.MaxSize) * int64(megabyte)
+}
+
+// rotated files of filename, oldest first
+func backupFiles(filename string) []string {
+	dir := filepath.Dir(filename)
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)] + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return []string{}
+	}
+	names := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		timestamp := strings.TrimPrefix(name, prefix)
+		timestamp = strings.TrimSuffix(timestamp, compressSuffix)
+		if !strings.HasSuffix(timestamp, ext) {
+			continue
+		}
+		timestamp = timestamp[:len(timestamp)-len(ext)]
+		if _, err := time.Parse(backupTimeFormat, timestamp); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	// the timestamp format sorts by time
+	sort.Strings(names)
+
+	backups := []string{}
+	for _, name := range names {
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	return backups
+}
+
+// files removed meanwhile, e.g. by MaxAge, are left out. Equal times keep
+// the order of files.
+func sortByModTime(files []string) []string {
+	type fileTime_t struct {
+		path    string
+		modTime time.Time
+	}
+	fileTimes := []fileTime_t{}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		fileTimes = append(fileTimes, fileTime_t{path: f, modTime: info.ModTime()})
+	}
+	sort.SliceStable(fileTimes, func(i, j int) bool {
+		return fileTimes[i].modTime.Before(fileTimes[j].modTime)
+	})
+	sorted := make([]string, 0, len(fileTimes))
+	for _, ft := range fileTimes {
+		sorted = append(sorted, ft.path)
+	}
+	return sorted
+}
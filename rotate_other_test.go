@@ -0,0 +1,66 @@
+//go:build !windows
+
+package zapLog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileMode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	// 0660 is reduced by the usual umask of 022 unless it is set again
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionFileMode, Value: os.FileMode(0660)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.GetLogger().Info("before rotation")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.GetLogger().Info("after rotation")
+
+	backups, _ := l.BackupFiles()
+	for _, f := range append(backups, path) {
+		info, err := os.Stat(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0660 {
+			t.Errorf("%s has mode %v, want 0660", filepath.Base(f), mode)
+		}
+	}
+	if len(backups) != 1 {
+		t.Errorf("got %d backups, want 1", len(backups))
+	}
+}
+
+func TestFileModeErrors(t *testing.T) {
+	dir := t.TempDir()
+	options := []LogOption_t{{Option: OptionDisableConsole, Value: true}, {Option: OptionFileMode, Value: os.FileMode(0400)}}
+	if _, err := NewLogger(filepath.Join(dir, "readonly.log"), options...); err == nil {
+		t.Error("NewLogger accepted a mode the owner can't write with")
+	}
+
+	l, err := NewLogger(filepath.Join(dir, "app.log"),
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionFileMode, Value: os.FileMode(0640)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	notDir := filepath.Join(dir, "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TeeLogger(filepath.Join(notDir, "tee.log"), LogLevelDebug); err == nil {
+		t.Error("TeeLogger below a regular file returned no error")
+	}
+}
@@ -0,0 +1,386 @@
+package zapLog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAddFileWriters(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := newTestLogger(t)
+	defer l.Close()
+	sizes := map[string]int{"small.log": 1, "large.log": 50}
+	uids := map[string]string{}
+	for name, size := range sizes {
+		_, uid, err := l.AddFileWriter(filepath.Join(dir, name), LogOption_t{Option: OptionLogMaxSize, Value: size})
+		if err != nil {
+			t.Fatal(err)
+		}
+		uids[name] = uid
+	}
+	l.GetLogger().Info("to both files")
+	l.Sync()
+
+	for name, size := range sizes {
+		w, _ := l.GetWriter(uids[name])
+		if got := w.(*fileWriter_t).logger.MaxSize; got != size {
+			t.Errorf("%s MaxSize = %d, want %d", name, got, size)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dir, name)); !strings.Contains(string(data), "to both files") {
+			t.Errorf("%s has %q", name, data)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path, LogOption_t{Option: OptionDisableConsole, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.GetLogger().Info("old file")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.GetLogger().Info("new file")
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "old file") || !strings.Contains(string(data), "new file") {
+		t.Errorf("current file has %q", data)
+	}
+	backups := backupFiles(path)
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want 1", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); !strings.Contains(string(data), "old file") {
+		t.Errorf("backup has %q", data)
+	}
+
+	nop := NewNop()
+	if err := nop.Rotate(); err != ErrSaveDisabled {
+		t.Errorf("Rotate without a file = %v, want ErrSaveDisabled", err)
+	}
+}
+
+func TestRotationOption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	rotation := Rotation_t{MaxSize: 7, MaxBackups: 3, MaxAge: 2, Compress: true, LocalTime: true}
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		// overridden by OptionRotation
+		LogOption_t{Option: OptionLogMaxSize, Value: 100},
+		LogOption_t{Option: OptionRotation, Value: rotation})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	lj := l.fileWriter.logger
+	got := Rotation_t{MaxSize: lj.MaxSize, MaxBackups: lj.MaxBackups, MaxAge: lj.MaxAge, Compress: lj.Compress, LocalTime: lj.LocalTime}
+	if got != rotation {
+		t.Errorf("lumberjack config = %+v, want %+v", got, rotation)
+	}
+	if config := l.Config(); config.MaxSize != 7 || config.MaxBackup != 3 {
+		t.Errorf("Config() = %+v, want the OptionRotation values", config)
+	}
+}
+
+func TestTeeLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.log")
+	l, buf := newTestLogger(t)
+	tee, err := l.TeeLogger(path, LogLevelDebug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tee.Debug("debug detail")
+	tee.Info("to both")
+	l.Close()
+
+	if out := buf.String(); strings.Contains(out, "debug detail") || !strings.Contains(out, "to both") {
+		t.Errorf("main writer got %q", out)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "debug detail") || !strings.Contains(string(data), "to both") {
+		t.Errorf("tee file has %q", data)
+	}
+}
+
+func TestOnRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	infos := []RotateInfo{}
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionOnRotate, Value: func(info RotateInfo) {
+			infos = append(infos, info)
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.GetLogger().Info("before rotation")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 1 {
+		t.Fatalf("OptionOnRotate called %d times, want 1", len(infos))
+	}
+	backup, err := os.Stat(infos[0].Backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if infos[0].Filename != path || infos[0].Size != backup.Size() {
+		t.Errorf("RotateInfo = %+v, backup size %d", infos[0], backup.Size())
+	}
+}
+
+func TestUpdateRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionLogMaxSize, Value: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	chunk := strings.Repeat("x", 100*1024)
+	logger := l.GetLogger()
+	for i := 0; i < 7; i++ {
+		logger.Info(chunk)
+	}
+	if backups := backupFiles(path); len(backups) != 0 {
+		t.Fatalf("rotated below 100 MB: %v", backups)
+	}
+
+	if err := l.UpdateRotation(LogOption_t{Option: OptionLogMaxSize, Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if l.Config().MaxSize != 1 {
+		t.Errorf("Config().MaxSize = %d, want 1", l.Config().MaxSize)
+	}
+	// the file passes 1 MB
+	for i := 0; i < 4; i++ {
+		logger.Info(chunk)
+	}
+	if backups := backupFiles(path); len(backups) != 1 {
+		t.Errorf("backups = %v, want one rotation", backups)
+	}
+
+	if err := l.UpdateRotation(LogOption_t{Option: OptionAddCaller, Value: true}); err == nil {
+		t.Error("UpdateRotation accepted a non rotation option")
+	}
+}
+
+func TestDualEncode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionDualEncode, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.GetLogger().Infow("both formats", "k", "v")
+	l.Close()
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "\tINFO\tboth formats\t") {
+		t.Errorf("first line = %q, want the console format", lines[0])
+	}
+	if m := decodeJSONLine(t, lines[1]); m["msg"] != "both formats" || m["k"] != "v" {
+		t.Errorf("second line = %q, want json", lines[1])
+	}
+}
+
+func TestBackupFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l, err := NewLogger(path, LogOption_t{Option: OptionDisableConsole, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// the modification times don't follow the names, e.g. after a copy
+	now := time.Now()
+	fakes := map[string]time.Duration{
+		"app-2024-01-03T00-00-00.000.log":    -3 * time.Hour,
+		"app-2024-01-01T00-00-00.000.log.gz": -2 * time.Hour,
+		"app-2024-01-02T00-00-00.000.log":    -1 * time.Hour,
+		"app-notatime.log":                   0,
+		"other-2024-01-01T00-00-00.000.log":  0,
+	}
+	for name, age := range fakes {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(file, now.Add(age), now.Add(age))
+	}
+
+	backups, err := l.BackupFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "app-2024-01-03T00-00-00.000.log"),
+		filepath.Join(dir, "app-2024-01-01T00-00-00.000.log.gz"),
+		filepath.Join(dir, "app-2024-01-02T00-00-00.000.log"),
+	}
+	if !reflect.DeepEqual(backups, want) {
+		t.Errorf("BackupFiles() = %v, want %v", backups, want)
+	}
+
+	if _, err := NewNop().BackupFiles(); err != ErrSaveDisabled {
+		t.Errorf("BackupFiles without a file = %v, want ErrSaveDisabled", err)
+	}
+}
+
+func TestRotateHookLogs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var l *Logger
+	hooked := make(chan string, 2)
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionLogMaxSize, Value: 1},
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionRotateHook, Value: func(oldPath string) {
+			l.GetLogger().Infow("rotated", "backup", oldPath)
+			hooked <- oldPath
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a rotation by size and a forced one, the hook runs within both
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		chunk := strings.Repeat("x", 100*1024)
+		for i := 0; i < 11; i++ {
+			l.GetLogger().Info(chunk)
+		}
+		l.Rotate()
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-hooked:
+		case <-time.After(5 * time.Second):
+			// Close would block as well
+			t.Fatal("the hook deadlocked")
+		}
+	}
+	<-done
+	l.Close()
+}
+
+func TestOnRotateLogs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var l *Logger
+	rotations := make(chan RotateInfo, 2)
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionLogMaxSize, Value: 1},
+		LogOption_t{Option: OptionOnRotate, Value: func(info RotateInfo) {
+			l.GetLogger().Infow("rotation metrics", "size", info.Size)
+			rotations <- info
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		chunk := strings.Repeat("x", 100*1024)
+		for i := 0; i < 11; i++ {
+			l.GetLogger().Info(chunk)
+		}
+		l.Rotate()
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case info := <-rotations:
+			if info.Size == 0 {
+				t.Errorf("RotateInfo = %+v, want the size of the rotated file", info)
+			}
+		case <-time.After(5 * time.Second):
+			// Close would block as well
+			t.Fatal("the callback deadlocked")
+		}
+	}
+	<-done
+	l.Close()
+}
+
+func TestTeeLoggerHooksAndFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tee.log")
+	hooks := 0
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionHooks, Value: []func(zapcore.Entry) error{func(zapcore.Entry) error {
+			hooks++
+			return nil
+		}}},
+		LogOption_t{Option: OptionInitialFields, Value: map[string]interface{}{"service": "api"}})
+	tee, err := l.TeeLogger(path, LogLevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tee.Info("to both")
+	l.Close()
+
+	if hooks != 1 {
+		t.Errorf("hooks ran %d times, want 1", hooks)
+	}
+	data, _ := os.ReadFile(path)
+	for name, out := range map[string]string{"main writer": buf.String(), "tee file": string(data)} {
+		if strings.Count(out, `"service": "api"`) != 1 {
+			t.Errorf("%s got %q, want the initial field once", name, out)
+		}
+	}
+
+	if _, err := l.TeeLogger(filepath.Join(path, "under a file.log"), LogLevelInfo); err == nil {
+		t.Error("TeeLogger under a file didn't fail")
+	}
+	if _, err := newLogger().TeeLogger(filepath.Join(dir, "early.log"), LogLevelInfo); err == nil {
+		t.Error("TeeLogger before Init didn't fail")
+	}
+}
+
+func TestUpdateRotationInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(path, LogOption_t{Option: OptionDisableConsole, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.GetLogger().Info("opens the file")
+	lj := l.fileWriter.logger
+
+	// every replaced lumberjack.Logger would leave a goroutine behind
+	goroutines := runtime.NumGoroutine()
+	for i := 1; i <= 20; i++ {
+		if err := l.UpdateRotation(LogOption_t{Option: OptionLogMaxSize, Value: i}); err != nil {
+			t.Fatal(err)
+		}
+		l.Rotate()
+	}
+	if l.fileWriter.logger != lj || lj.MaxSize != 20 {
+		t.Errorf("lumberjack.Logger replaced or not updated, MaxSize = %d", l.fileWriter.logger.MaxSize)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines+2 {
+		t.Errorf("%d goroutines after 20 updates, %d before", n, goroutines)
+	}
+}
@@ -0,0 +1,192 @@
+package zapLog
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// open files of a routing logger, the least recently used one is closed
+// when a new value needs a file
+const maxRoutedFiles = 64
+
+// rotated files in one directory, one per value of the routing field
+type router_t struct {
+	mu          sync.Mutex
+	field       string
+	dir         string
+	optionTable map[OptionType_e]interface{}
+	files       map[string]*list.Element
+	// front is the most recently used
+	lru *list.List
+}
+
+type routedFile_t struct {
+	value  string
+	writer *fileWriter_t
+}
+
+// logger writing each entry to dir/<value>.log where value is the value of
+// field on the entry, given on the call or attached by With. Entries without
+// the field only go to the console. Files are opened on first use and the
+// rotation options apply to each of them.
+func NewRoutingLogger(field string, dir string, options ...LogOption_t) (*Logger, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("zapLog: create log directory: %w", err)
+	}
+	l := newLogger()
+	l.router = &router_t{
+		field: field,
+		dir:   dir,
+		files: map[string]*list.Element{},
+		lru:   list.New(),
+	}
+	options = append(options, LogOption_t{Option: OptionLogDisableSave, Value: true})
+	if err := l.init("", options...); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// the rotation options for files opened from now on
+func (r *router_t) setOptionTable(optionTable map[OptionType_e]interface{}) {
+	table := make(map[OptionType_e]interface{}, len(optionTable))
+	for k, v := range optionTable {
+		table[k] = v
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.optionTable = table
+}
+
+func (r *router_t) write(value string, p []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// the lock is held during the write so an evicted file is not reopened
+	w, err := r.writer(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(p)
+	return err
+}
+
+// the caller must hold r.mu
+func (r *router_t) writer(value string) (*fileWriter_t, error) {
+	if e, ok := r.files[value]; ok {
+		r.lru.MoveToFront(e)
+		return e.Value.(*routedFile_t).writer, nil
+	}
+	if r.lru.Len() >= maxRoutedFiles {
+		oldest := r.lru.Back()
+		f := oldest.Value.(*routedFile_t)
+		f.writer.Close()
+		r.lru.Remove(oldest)
+		delete(r.files, f.value)
+	}
+	path := filepath.Join(r.dir, routeFileName(value))
+	w, err := newRotateFileWriter(path, r.optionTable)
+	if err != nil {
+		return nil, err
+	}
+	f := &routedFile_t{value: value, writer: w}
+	r.files[value] = r.lru.PushFront(f)
+	return f.writer, nil
+}
+
+func (r *router_t) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var err error
+	for e := r.lru.Front(); e != nil; e = e.Next() {
+		if closeErr := e.Value.(*routedFile_t).writer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	r.files = map[string]*list.Element{}
+	r.lru.Init()
+	return err
+}
+
+// the value must not leave dir
+func routeFileName(value string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(value)
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+	return name + ".log"
+}
+
+// encodes entries itself like zapcore.NewCore but picks the file per entry
+type routingCore_t struct {
+	zapcore.LevelEnabler
+	router *router_t
+	enc    zapcore.Encoder
+	// value attached by With, empty when the entry has to carry it
+	value string
+}
+
+func newRoutingCore(router *router_t, enc zapcore.Encoder, level zapcore.LevelEnabler) zapcore.Core {
+	return &routingCore_t{LevelEnabler: level, router: router, enc: enc}
+}
+
+func (c *routingCore_t) With(fields []zapcore.Field) zapcore.Core {
+	clone := &routingCore_t{
+		LevelEnabler: c.LevelEnabler,
+		router:       c.router,
+		enc:          c.enc.Clone(),
+		value:        c.value,
+	}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	if value, ok := routeValue(c.router.field, fields); ok {
+		clone.value = value
+	}
+	return clone
+}
+
+func (c *routingCore_t) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *routingCore_t) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	value := c.value
+	if v, ok := routeValue(c.router.field, fields); ok {
+		value = v
+	}
+	if value == "" {
+		return nil
+	}
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	return c.router.write(value, buf.Bytes())
+}
+
+func (c *routingCore_t) Sync() error {
+	return nil
+}
+
+// the last field named field, as text
+func routeValue(field string, fields []zapcore.Field) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key != field {
+			continue
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		fields[i].AddTo(enc)
+		return fmt.Sprint(enc.Fields[field]), true
+	}
+	return "", false
+}
@@ -0,0 +1,37 @@
+package zapLog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoutingLogger(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewRoutingLogger("tenant", dir, LogOption_t{Option: OptionDisableConsole, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := l.GetLogger()
+	logger.Infow("for acme", "tenant", "acme")
+	logger.With("tenant", "globex").Info("for globex")
+	logger.Info("no tenant")
+	l.Close()
+
+	for value, msg := range map[string]string{"acme": "for acme", "globex": "for globex"} {
+		data, err := os.ReadFile(filepath.Join(dir, value+".log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], msg) {
+			t.Errorf("%s.log has %q", value, data)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("got %d files, want 2", len(entries))
+	}
+	if name := routeFileName("../x"); name != ".._x.log" {
+		t.Errorf("routeFileName(../x) = %q", name)
+	}
+}
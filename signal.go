@@ -0,0 +1,85 @@
+package zapLog
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// how long a signal waits for the writers before it is passed on
+const signalDrainTimeout = 5 * time.Second
+
+// sends the signal to the own process again, replaced in tests
+var raiseSignal = func(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		p.Signal(sig)
+	}
+}
+
+// on each of signals flush the buffered entries. The handlers of the app
+// still get the signal once and decide what happens next. A listed signal
+// no longer ends the process by itself, so only pass the signals the app
+// handles, without signals nothing is installed. Apps without handlers use
+// InstallSignalDrain. The returned func removes the handler.
+func (l *Logger) InstallSignalFlush(signals ...os.Signal) func() {
+	if len(signals) == 0 {
+		return func() {}
+	}
+	return notifySignals(signals, l.syncOnSignal)
+}
+
+// on the first of signals, os.Interrupt and SIGTERM by default, drain the
+// writers and send the signal again so its default action runs, e.g. the
+// process exits. Meant for apps without signal handlers of their own, those
+// would get the signal twice and lose what they log after the drain.
+// The returned func removes the handler.
+func (l *Logger) InstallSignalDrain(signals ...os.Signal) func() {
+	return notifySignals(signals, l.drainOnSignal)
+}
+
+// keeps listening until handle returns true or the returned func is called
+func notifySignals(signals []os.Signal, handle func(sig os.Signal, ch chan os.Signal) bool) func() {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case sig := <-ch:
+				if handle(sig, ch) {
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		signal.Stop(ch)
+		once.Do(func() {
+			close(stop)
+		})
+	}
+}
+
+func (l *Logger) syncOnSignal(os.Signal, chan os.Signal) bool {
+	l.Sync()
+	return false
+}
+
+func (l *Logger) drainOnSignal(sig os.Signal, ch chan os.Signal) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), signalDrainTimeout)
+	l.Drain(ctx)
+	cancel()
+	// the default action of the signal runs again
+	signal.Stop(ch)
+	raiseSignal(sig)
+	return true
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+
+package zapLog
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInstallSignalFlush(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionFlushInterval, Value: time.Hour},
+	)
+	// the handler of the app, it must get the signal once
+	app := make(chan os.Signal, 2)
+	signal.Notify(app, syscall.SIGUSR1)
+	defer signal.Stop(app)
+	stop := l.InstallSignalFlush(syscall.SIGUSR1)
+	defer stop()
+
+	l.GetLogger().Info("buffered")
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	select {
+	case <-app:
+	case <-time.After(time.Second):
+		t.Fatal("the app handler didn't get the signal")
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(buf.lines()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if lines := buf.lines(); len(lines) != 1 {
+		t.Errorf("got %q, want the buffered entry flushed", lines)
+	}
+	select {
+	case sig := <-app:
+		t.Errorf("the app handler got %v twice", sig)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// SIGTERM still ends a process without handlers of its own
+func TestInstallSignalFlushKeepsTerm(t *testing.T) {
+	if os.Getenv("ZAPLOG_SIGNAL_CHILD") == "1" {
+		stop := NewNop().InstallSignalFlush()
+		defer stop()
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		time.Sleep(5 * time.Second)
+		os.Exit(0)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInstallSignalFlushKeepsTerm$")
+	cmd.Env = append(os.Environ(), "ZAPLOG_SIGNAL_CHILD=1")
+	err := cmd.Run()
+	if cmd.ProcessState == nil {
+		t.Fatal(err)
+	}
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Errorf("child ended with %v, want killed by SIGTERM", err)
+	}
+}
@@ -0,0 +1,52 @@
+package zapLog
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSyncOnSignal(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionFlushInterval, Value: time.Hour},
+	)
+	l.GetLogger().Info("buffered")
+	if lines := buf.lines(); len(lines) != 0 {
+		t.Fatalf("entry written before the signal: %q", lines)
+	}
+
+	if stop := l.syncOnSignal(os.Interrupt, nil); stop {
+		t.Error("InstallSignalFlush stops listening after the first signal")
+	}
+	if lines := buf.lines(); len(lines) != 1 {
+		t.Errorf("got %q after the signal, want the buffered entry", lines)
+	}
+	l.GetLogger().Info("still logging")
+	l.Sync()
+	if lines := buf.lines(); len(lines) != 2 {
+		t.Errorf("got %q, want the logger usable after the signal", lines)
+	}
+}
+
+func TestDrainOnSignal(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionFlushInterval, Value: time.Hour},
+	)
+	raised := []os.Signal{}
+	defer func(raise func(os.Signal)) { raiseSignal = raise }(raiseSignal)
+	raiseSignal = func(sig os.Signal) { raised = append(raised, sig) }
+
+	l.GetLogger().Info("buffered")
+	ch := make(chan os.Signal, 1)
+	if stop := l.drainOnSignal(os.Interrupt, ch); !stop {
+		t.Error("InstallSignalDrain keeps listening after the signal")
+	}
+	if lines := buf.lines(); len(lines) != 1 {
+		t.Errorf("got %q after the signal, want the buffered entry", lines)
+	}
+	if len(raised) != 1 || raised[0] != os.Interrupt {
+		t.Errorf("raised %v, want the interrupt once", raised)
+	}
+}
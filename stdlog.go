@@ -0,0 +1,45 @@
+package zapLog
+
+import (
+	"bytes"
+	"io"
+	"log"
+)
+
+// frames between the user and logAt for the standard logger:
+// caller -> log.Println -> log output -> stdWriter_t.Write
+const stdLogCallerSkip = 4
+
+type stdWriter_t struct {
+	logger *Logger
+	level  LogLevel_e
+}
+
+// every Write becomes one entry at level with the trailing newline removed,
+// the caller is reported right for writes by the standard log package
+func (l *Logger) StdWriter(level LogLevel_e) io.Writer {
+	return &stdWriter_t{logger: l, level: level}
+}
+
+func (w *stdWriter_t) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimRight(p, "\r\n"))
+	logAt(w.logger.helperLogger(stdLogCallerSkip), w.level, msg)
+	return len(p), nil
+}
+
+// send the output of the standard logger to l at info level, the returned
+// func restores the previous output, flags and prefix
+func (l *Logger) RedirectStdLog() func() {
+	flags := log.Flags()
+	prefix := log.Prefix()
+	output := log.Writer()
+	// zap adds the timestamp and caller
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(l.StdWriter(LogLevelInfo))
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(output)
+	}
+}
@@ -0,0 +1,36 @@
+package zapLog
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionAddCaller, Value: true})
+	var previous bytes.Buffer
+	log.SetOutput(&previous)
+	restore := l.RedirectStdLog()
+	log.Println("from the std logger")
+	restore()
+	if log.Writer() != &previous {
+		t.Error("restore didn't bring back the previous output")
+	}
+	log.SetOutput(os.Stderr)
+
+	lines := buf.lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "from the std logger") || !strings.Contains(lines[0], "INFO") {
+		t.Errorf("entry = %q", lines[0])
+	}
+	if !strings.Contains(lines[0], "stdlog_test.go") {
+		t.Errorf("caller is not the test: %q", lines[0])
+	}
+	if previous.Len() != 0 {
+		t.Errorf("previous output got %q", previous.String())
+	}
+}
@@ -0,0 +1,40 @@
+package zapLog
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logger writing to the current outputs and also to its own rotated file at
+// path with its own level, e.g. a subsystem that wants a debug file while the
+// main log stays at info. The file is closed by Close.
+func (l *Logger) TeeLogger(path string, level LogLevel_e) (*zap.SugaredLogger, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.coreLogger == nil {
+		return nil, errors.New("zapLog: logger is not initialized")
+	}
+	if l.optionTable[OptionDiscard].(bool) {
+		return l.sugarLogger, nil
+	}
+	if err := checkLogPath(path); err != nil {
+		return nil, err
+	}
+	w, err := newRotateFileWriter(path, l.optionTable)
+	if err != nil {
+		return nil, err
+	}
+	l.teeWriters = append(l.teeWriters, w)
+	// the hooks of the logger already run for every entry, the file core
+	// only needs the initial fields
+	fileCore := l.buildCore([]writerInfo_t{{
+		writer: w,
+		level:  &level,
+		kind:   writerKindFile,
+	}}, false, false).With(l.initialFields())
+	return l.coreLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newTeeCore(core, fileCore)
+	})).Sugar(), nil
+}
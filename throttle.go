@@ -0,0 +1,70 @@
+package zapLog
+
+import (
+	"go.uber.org/zap"
+)
+
+// frames between the user and the sugared logger call in logAt for the
+// LogEvery style helpers: caller -> exported helper -> internal helper -> logAt
+const helperCallerSkip = 3
+
+// log the first call of key and then every n-th one, args are key value pairs
+func (l *Logger) LogEvery(key string, n int, level LogLevel_e, msg string, args ...interface{}) {
+	l.logEvery(key, n, level, msg, args...)
+}
+
+// log key only the first time it is seen
+func (l *Logger) LogOnce(key string, level LogLevel_e, msg string, args ...interface{}) {
+	l.logOnce(key, level, msg, args...)
+}
+
+func (l *Logger) logEvery(key string, n int, level LogLevel_e, msg string, args ...interface{}) {
+	l.throttleMu.Lock()
+	count := l.throttle[key]
+	l.throttle[key] = count + 1
+	l.throttleMu.Unlock()
+
+	if n > 1 && count%uint64(n) != 0 {
+		return
+	}
+	logAt(l.helperLogger(helperCallerSkip), level, msg, args...)
+}
+
+func (l *Logger) logOnce(key string, level LogLevel_e, msg string, args ...interface{}) {
+	l.throttleMu.Lock()
+	_, seen := l.once[key]
+	l.once[key] = struct{}{}
+	l.throttleMu.Unlock()
+
+	if seen {
+		return
+	}
+	logAt(l.helperLogger(helperCallerSkip), level, msg, args...)
+}
+
+// logger for helpers that log for their caller, nil before Init
+func (l *Logger) helperLogger(skip int) *zap.SugaredLogger {
+	sugarLogger := l.GetLogger()
+	if sugarLogger == nil {
+		return nil
+	}
+	return sugarLogger.WithOptions(zap.AddCallerSkip(skip))
+}
+
+func logAt(sugarLogger *zap.SugaredLogger, level LogLevel_e, msg string, args ...interface{}) {
+	if sugarLogger == nil {
+		return
+	}
+	switch level {
+	case LogLevelDebug:
+		sugarLogger.Debugw(msg, args...)
+	case LogLevelWarn:
+		sugarLogger.Warnw(msg, args...)
+	case LogLevelError:
+		sugarLogger.Errorw(msg, args...)
+	case LogLevelFatal:
+		sugarLogger.Fatalw(msg, args...)
+	default:
+		sugarLogger.Infow(msg, args...)
+	}
+}
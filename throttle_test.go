@@ -0,0 +1,38 @@
+package zapLog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogEvery(t *testing.T) {
+	l, buf := newTestLogger(t)
+	for i := 0; i < 10; i++ {
+		l.LogEvery("retry", 3, LogLevelInfo, "retrying")
+	}
+	l.LogEvery("other", 3, LogLevelInfo, "other key")
+
+	// calls 1, 4, 7 and 10 of "retry", the first call of "other"
+	lines := buf.lines()
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[4], "other key") {
+		t.Errorf("keys share a counter: %q", lines[4])
+	}
+}
+
+func TestLogOnce(t *testing.T) {
+	l, buf := newTestLogger(t, LogOption_t{Option: OptionAddCaller, Value: true})
+	for i := 0; i < 3; i++ {
+		l.LogOnce("deprecated", LogLevelWarn, "deprecated call")
+	}
+
+	lines := buf.lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "throttle_test.go") {
+		t.Errorf("caller is not the test: %q", lines[0])
+	}
+}
@@ -0,0 +1,16 @@
+package zapLog
+
+import "time"
+
+// frames between the user and the sugared logger call for the func returned
+// by Timed: caller -> returned func -> logAt
+const timedCallerSkip = 2
+
+// defer l.Timed("load config")() logs msg with the time the surrounding
+// func took in "duration"
+func (l *Logger) Timed(msg string) func() {
+	start := time.Now()
+	return func() {
+		logAt(l.helperLogger(timedCallerSkip), LogLevelInfo, msg, "duration", time.Since(start))
+	}
+}
@@ -0,0 +1,32 @@
+package zapLog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimed(t *testing.T) {
+	l, buf := newTestLogger(t,
+		LogOption_t{Option: OptionEncoderFormat, Value: EncoderFormatJson},
+		LogOption_t{Option: OptionAddCaller, Value: true})
+	start := time.Now()
+	func() {
+		defer l.Timed("load config")()
+		time.Sleep(20 * time.Millisecond)
+	}()
+	elapsed := time.Since(start)
+
+	m := decodeJSONLine(t, buf.lines()[0])
+	// seconds with the production encoder config
+	seconds, ok := m["duration"].(float64)
+	if !ok {
+		t.Fatalf("duration = %v", m["duration"])
+	}
+	if d := time.Duration(seconds * float64(time.Second)); d < 20*time.Millisecond || d > elapsed {
+		t.Errorf("duration = %v, want between 20ms and %v", d, elapsed)
+	}
+	if caller, _ := m["caller"].(string); !strings.Contains(caller, "timed_test.go") {
+		t.Errorf("caller = %q, want the test", caller)
+	}
+}
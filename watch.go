@@ -0,0 +1,116 @@
+package zapLog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// how often WatchConfig looks at the modification time of the file
+const configWatchInterval = time.Second
+
+// settings WatchConfig reads, missing keys keep their current value
+type fileConfig_t struct {
+	Level      *string `json:"level"`
+	MaxSize    *int    `json:"maxSize"`
+	MaxBackups *int    `json:"maxBackups"`
+	MaxAge     *int    `json:"maxAge"`
+	Compress   *bool   `json:"compress"`
+}
+
+// apply the json config at path, e.g. {"level": "debug", "maxSize": 10},
+// now and again on SIGHUP or when the file changes. A config that fails to
+// parse or validate is logged and the current settings stay. The returned
+// func stops watching, calling it again does nothing.
+func (l *Logger) WatchConfig(path string) (func(), error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("zapLog: watch config: %w", err)
+	}
+	if err := l.loadConfig(path); err != nil {
+		return nil, err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(configWatchInterval)
+		defer ticker.Stop()
+		modTime := info.ModTime()
+		for {
+			reload := false
+			select {
+			case <-stop:
+				return
+			case <-hup:
+				reload = true
+			case <-ticker.C:
+				if info, err := os.Stat(path); err == nil && !info.ModTime().Equal(modTime) {
+					modTime = info.ModTime()
+					reload = true
+				}
+			}
+			if !reload {
+				continue
+			}
+			if err := l.loadConfig(path); err != nil {
+				if sugarLogger := l.GetLogger(); sugarLogger != nil {
+					sugarLogger.Errorw("zapLog: config not reloaded", "path", path, "error", err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		signal.Stop(hup)
+		once.Do(func() {
+			close(stop)
+		})
+	}, nil
+}
+
+func (l *Logger) loadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("zapLog: read config: %w", err)
+	}
+	config := fileConfig_t{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("zapLog: parse config %s: %w", path, err)
+	}
+
+	// validate everything before changing anything
+	level := LogLevelInfo
+	if config.Level != nil {
+		if level, err = ParseLevel(*config.Level); err != nil {
+			return err
+		}
+	}
+	options := []LogOption_t{}
+	if config.MaxSize != nil {
+		options = append(options, LogOption_t{Option: OptionLogMaxSize, Value: *config.MaxSize})
+	}
+	if config.MaxBackups != nil {
+		options = append(options, LogOption_t{Option: OptionLogMaxBackup, Value: *config.MaxBackups})
+	}
+	if config.MaxAge != nil {
+		options = append(options, LogOption_t{Option: OptionLogMaxAge, Value: *config.MaxAge})
+	}
+	if config.Compress != nil {
+		options = append(options, LogOption_t{Option: OptionLogCompress, Value: *config.Compress})
+	}
+	if len(options) > 0 {
+		if err := l.UpdateRotation(options...); err != nil {
+			return err
+		}
+	}
+	if config.Level != nil {
+		l.ChangeLogLevel(level)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package zapLog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.json")
+	if err := os.WriteFile(path, []byte(`{"level": "warn"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, _ := newTestLogger(t)
+	stop, err := l.WatchConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	// a deferred stop after an explicit one must not panic
+	defer stop()
+	if l.Enabled(LogLevelInfo) {
+		t.Fatal("initial config not applied")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"level": "debug"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the write can keep the mtime on a coarse clock, move it so the change is seen
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	deadline := time.Now().Add(3 * configWatchInterval)
+	for !l.Enabled(LogLevelDebug) {
+		if time.Now().After(deadline) {
+			t.Fatal("config change not applied")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestWatchConfigInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.json")
+	if err := os.WriteFile(path, []byte(`{"level": "verbose"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, _ := newTestLogger(t)
+	if _, err := l.WatchConfig(path); err == nil {
+		t.Error("WatchConfig accepted an unknown level")
+	}
+	if _, err := l.WatchConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("WatchConfig accepted a missing file")
+	}
+}
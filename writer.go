@@ -0,0 +1,131 @@
+package zapLog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// writer taking every Write as one entry
+type entryWriter_i interface {
+	writesEntries()
+}
+
+// unlike io.MultiWriter every writer gets the entry even when an earlier
+// one failed, the errors are collected and name the failing writers
+type multiWriteSyncer_t []writerInfo_t
+
+func newMultiWriteSyncer(writerList []writerInfo_t) multiWriteSyncer_t {
+	return append(multiWriteSyncer_t{}, writerList...)
+}
+
+func (m multiWriteSyncer_t) Write(p []byte) (int, error) {
+	var err error
+	for _, w := range m {
+		err = multierr.Append(err, w.write(p))
+	}
+	return len(p), err
+}
+
+func (m multiWriteSyncer_t) Sync() error {
+	var err error
+	for _, w := range m {
+		err = multierr.Append(err, w.sync())
+	}
+	return err
+}
+
+// same as a multiWriteSyncer_t of one writer without the loop, most setups
+// log to the file or stdout only
+type singleWriteSyncer_t struct {
+	writerInfo_t
+}
+
+func (s singleWriteSyncer_t) Write(p []byte) (int, error) {
+	return len(p), s.write(p)
+}
+
+func (s singleWriteSyncer_t) Sync() error {
+	return s.sync()
+}
+
+func (w writerInfo_t) write(p []byte) error {
+	n, err := w.writer.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		if w.fallback != nil {
+			if _, fallbackErr := w.fallback.Write(p); fallbackErr == nil {
+				return fmt.Errorf("zapLog: write to %s, written to the fallback writer: %w", w.name(), err)
+			}
+		}
+		return fmt.Errorf("zapLog: write to %s: %w", w.name(), err)
+	}
+	return nil
+}
+
+func (w writerInfo_t) sync() error {
+	// syncing a terminal or pipe fails, there is nothing to flush anyway
+	if w.writer == os.Stdout || w.writer == os.Stderr {
+		return nil
+	}
+	if s, ok := w.writer.(interface{ Sync() error }); ok {
+		if err := s.Sync(); err != nil {
+			return fmt.Errorf("zapLog: sync %s: %w", w.name(), err)
+		}
+	}
+	return nil
+}
+
+type syncWriteSyncer_t struct {
+	zapcore.WriteSyncer
+}
+
+func (s syncWriteSyncer_t) Write(p []byte) (int, error) {
+	n, err := s.WriteSyncer.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, s.WriteSyncer.Sync()
+}
+
+// used in error messages
+func (w writerInfo_t) name() string {
+	switch w.kind {
+	case writerKindFile:
+		return "log file"
+	case writerKindConsole:
+		return "console"
+	}
+	return "writer " + w.uid
+}
+
+// the writers behind the OptionBufferSize buffer, swapped on a rebuild so
+// the buffer and the loggers writing to it stay
+type swapWriteSyncer_t struct {
+	mu sync.RWMutex
+	ws zapcore.WriteSyncer
+}
+
+func (s *swapWriteSyncer_t) set(ws zapcore.WriteSyncer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ws = ws
+}
+
+func (s *swapWriteSyncer_t) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ws.Write(p)
+}
+
+func (s *swapWriteSyncer_t) Sync() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ws.Sync()
+}
@@ -0,0 +1,129 @@
+package zapLog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func BenchmarkWriteSyncer(b *testing.B) {
+	entry := []byte("2024-01-02 15:04:05\tINFO\tbenchmark\n")
+	writers := []writerInfo_t{{writer: io.Discard}}
+	for name, ws := range map[string]interface{ Write([]byte) (int, error) }{
+		"single": getWriter(writers),
+		"multi":  newMultiWriteSyncer(writers),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ws.Write(entry)
+			}
+		})
+	}
+}
+
+func TestSingleWriteSyncer(t *testing.T) {
+	buf := &syncBuffer_t{}
+	ws := getWriter([]writerInfo_t{{writer: buf}})
+	if _, ok := ws.(singleWriteSyncer_t); !ok {
+		t.Fatalf("getWriter of one writer = %T, want singleWriteSyncer_t", ws)
+	}
+	if n, err := ws.Write([]byte("entry\n")); n != 6 || err != nil {
+		t.Errorf("Write = %d, %v", n, err)
+	}
+	if buf.String() != "entry\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type failingWriter_t struct{}
+
+func (failingWriter_t) Write(p []byte) (int, error) {
+	return 0, errWriteFailed
+}
+
+func TestMultiWriteSyncerFailure(t *testing.T) {
+	first, last := &syncBuffer_t{}, &syncBuffer_t{}
+	ws := newMultiWriteSyncer([]writerInfo_t{
+		{writer: first, uid: "first"},
+		{writer: failingWriter_t{}, uid: "failing"},
+		{writer: last, uid: "last"},
+	})
+	_, err := ws.Write([]byte("entry\n"))
+	if !errors.Is(err, errWriteFailed) || !strings.Contains(err.Error(), "writer failing") {
+		t.Errorf("Write error = %v, want the error of the failing writer", err)
+	}
+	if first.String() != "entry\n" || last.String() != "entry\n" {
+		t.Errorf("writers got %q and %q", first.String(), last.String())
+	}
+}
+
+func TestInternalErrorWriter(t *testing.T) {
+	internal := &syncBuffer_t{}
+	l, _ := newTestLogger(t, LogOption_t{Option: OptionInternalErrorWriter, Value: internal})
+	l.AddWriter(failingWriter_t{})
+	l.GetLogger().Info("entry")
+
+	if !strings.Contains(internal.String(), errWriteFailed.Error()) {
+		t.Errorf("internal error writer got %q", internal.String())
+	}
+}
+
+func TestSyncOnWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	// the buffer would hold the entry until the flush interval
+	l, err := NewLogger(path,
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionBufferSize, Value: 4096},
+		LogOption_t{Option: OptionSyncOnWrite, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.GetLogger().Info("synced entry")
+
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "synced entry") {
+		t.Errorf("log file has %q before Sync", data)
+	}
+}
+
+func TestFallbackWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	internal := &syncBuffer_t{}
+	l, err := NewLogger(filepath.Join(dir, "app.log"),
+		LogOption_t{Option: OptionDisableConsole, Value: true},
+		LogOption_t{Option: OptionInternalErrorWriter, Value: internal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	fallback := &syncBuffer_t{}
+	l.SetFallbackWriter(fallback)
+	// lumberjack opens the file on the first write and can't once the
+	// directory is a file
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	l.GetLogger().Info("disk trouble")
+
+	if !strings.Contains(fallback.String(), "disk trouble") {
+		t.Errorf("fallback writer got %q", fallback.String())
+	}
+	if !strings.Contains(internal.String(), "fallback writer") {
+		t.Errorf("internal error writer got %q", internal.String())
+	}
+
+	l.SetFallbackWriter(nil)
+	l.GetLogger().Info("dropped")
+	if strings.Contains(fallback.String(), "dropped") {
+		t.Errorf("removed fallback writer got %q", fallback.String())
+	}
+}